@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache 进程内的读缓存，取出的值与其他请求共享，调用方不能修改
+type Cache interface {
+	// Get 获取缓存的值，不存在或已过期时ok为false
+	Get(key string) (value interface{}, ok bool)
+	// Set 写入缓存
+	Set(key string, value interface{})
+	// Delete 删除指定的键
+	Delete(keys ...string)
+	// DeletePrefix 删除以prefix开头的全部键
+	DeletePrefix(prefix string)
+	// Stats 命中统计
+	Stats() Stats
+}
+
+// Stats 缓存的命中统计
+type Stats struct {
+	Driver    string
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // 因容量不足被淘汰的数量，不包括过期和主动删除
+	Entries   int
+	Capacity  int
+}
+
+var (
+	current Cache = NewNoop()
+	group   singleflight.Group
+
+	// generation 每次删除缓存时加1，加载期间发生过删除时不写入加载结果，避免写入旧数据
+	generation atomic.Uint64
+)
+
+// Init 根据配置初始化读缓存，未调用时不缓存
+func Init() error {
+	cfg := config.AppConfig.Cache
+
+	switch cfg.Driver {
+	case "memory", "":
+		if cfg.Size <= 0 {
+			current = NewNoop()
+			break
+		}
+		current = NewMemory(cfg.Size, time.Duration(cfg.TTL)*time.Second)
+	case "none":
+		current = NewNoop()
+	default:
+		return fmt.Errorf("未知的缓存类型: %s", cfg.Driver)
+	}
+
+	log.Printf("读缓存初始化成功: %s\n", current.Stats().Driver)
+	return nil
+}
+
+// GetCache 获取缓存实例
+func GetCache() Cache {
+	return current
+}
+
+// Load 获取key对应的值，未命中时调用load加载并写入缓存；同一个key同时只有一个加载在执行，其他请求等待其结果
+func Load(key string, load func() (interface{}, error)) (interface{}, error) {
+	if value, ok := current.Get(key); ok {
+		return value, nil
+	}
+
+	value, err, _ := group.Do(key, func() (interface{}, error) {
+		gen := generation.Load()
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if generation.Load() == gen {
+			current.Set(key, value)
+		}
+		return value, nil
+	})
+	return value, err
+}
+
+// Delete 删除指定的键，数据修改后调用
+func Delete(keys ...string) {
+	generation.Add(1)
+	current.Delete(keys...)
+}
+
+// DeletePrefix 删除以prefix开头的全部键，数据修改后调用
+func DeletePrefix(prefix string) {
+	generation.Add(1)
+	current.DeletePrefix(prefix)
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+)
+
+// useCache 测试期间使用c作为全局缓存
+func useCache(t *testing.T, c Cache) {
+	t.Helper()
+	previous := current
+	current = c
+	t.Cleanup(func() { current = previous })
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.CacheConfig
+		driver  string
+		wantErr bool
+	}{
+		{"内存缓存", config.CacheConfig{Driver: "memory", Size: 10}, "memory", false},
+		{"默认为内存缓存", config.CacheConfig{Size: 10}, "memory", false},
+		{"容量为0时不缓存", config.CacheConfig{Driver: "memory", Size: 0}, "none", false},
+		{"关闭缓存", config.CacheConfig{Driver: "none", Size: 10}, "none", false},
+		{"未知类型", config.CacheConfig{Driver: "redis", Size: 10}, "", true},
+	}
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCache(t, NewNoop())
+			config.AppConfig = &config.Config{Cache: tt.cfg}
+			err := Init()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 %v，期望返回错误 %t", err, tt.wantErr)
+			}
+			if err == nil && GetCache().Stats().Driver != tt.driver {
+				t.Errorf("缓存类型 %s，期望 %s", GetCache().Stats().Driver, tt.driver)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	errLoad := errors.New("加载失败")
+	tests := []struct {
+		name      string
+		load      func(calls int) (interface{}, error)
+		wantErr   error
+		wantCalls int // 连续调用两次Load时load的执行次数
+	}{
+		{"结果写入缓存", func(calls int) (interface{}, error) { return calls, nil }, nil, 1},
+		{"错误不写入缓存", func(int) (interface{}, error) { return nil, errLoad }, errLoad, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCache(t, NewMemory(10, 0))
+			calls := 0
+			for i := 0; i < 2; i++ {
+				value, err := Load("key", func() (interface{}, error) {
+					calls++
+					return tt.load(calls)
+				})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("错误 %v，期望 %v", err, tt.wantErr)
+				}
+				if err == nil && value != 1 {
+					t.Errorf("值 %v，期望第一次加载的结果 1", value)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("加载 %d 次，期望 %d 次", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestLoadSingleflight(t *testing.T) {
+	useCache(t, NewNoop())
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	load := func() (interface{}, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return "value", nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = Load("key", load)
+	}()
+	<-started
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = Load("key", load)
+		}(i)
+	}
+	// 等待其他请求进入等待
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("同时加载同一个键执行了 %d 次，期望 1 次", got)
+	}
+	for i, value := range results {
+		if value != "value" {
+			t.Errorf("第%d个请求的结果 %v，期望 value", i, value)
+		}
+	}
+}
+
+func TestLoadGeneration(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func()
+		cached     bool
+	}{
+		{"加载期间未删除时写入缓存", func() {}, true},
+		{"加载期间删除键时不写入缓存", func() { Delete("other") }, false},
+		{"加载期间按前缀删除时不写入缓存", func() { DeletePrefix("other:") }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCache(t, NewMemory(10, 0))
+			value, err := Load("key", func() (interface{}, error) {
+				tt.invalidate()
+				return "stale", nil
+			})
+			if err != nil || value != "stale" {
+				t.Fatalf("Load = %v, %v，期望返回加载结果", value, err)
+			}
+			if _, ok := current.Get("key"); ok != tt.cached {
+				t.Errorf("写入缓存 %t，期望 %t", ok, tt.cached)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	useCache(t, NewMemory(10, 0))
+	current.Set("article:1", 1)
+	current.Set("article:2", 2)
+	current.Set("articles:latest", 3)
+
+	Delete("article:1")
+	DeletePrefix("articles:")
+
+	for key, want := range map[string]bool{"article:1": false, "article:2": true, "articles:latest": false} {
+		if _, ok := current.Get(key); ok != want {
+			t.Errorf("%s 在缓存中 %t，期望 %t", key, ok, want)
+		}
+	}
+}
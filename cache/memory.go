@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory 容量有限的LRU内存缓存，条目超过ttl后过期，ttl为0时不过期
+type Memory struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List // 最近使用的在前
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// memoryEntry 缓存条目
+type memoryEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewMemory 创建内存缓存，capacity为最多保存的条目数
+func NewMemory(capacity int, ttl time.Duration) *Memory {
+	return &Memory{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get 实现 Cache
+func (m *Memory) Get(key string) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.items[key]
+	if !ok {
+		m.misses++
+		return nil, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.remove(elem)
+		m.misses++
+		return nil, false
+	}
+
+	m.order.MoveToFront(elem)
+	m.hits++
+	return entry.value, true
+}
+
+// Set 实现 Cache，超过容量时淘汰最久未使用的条目
+func (m *Memory) Set(key string, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if m.ttl > 0 {
+		expiresAt = time.Now().Add(m.ttl)
+	}
+
+	if elem, ok := m.items[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(elem)
+		return
+	}
+
+	m.items[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for m.order.Len() > m.capacity {
+		m.remove(m.order.Back())
+		m.evictions++
+	}
+}
+
+// Delete 实现 Cache
+func (m *Memory) Delete(keys ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := m.items[key]; ok {
+			m.remove(elem)
+		}
+	}
+}
+
+// DeletePrefix 实现 Cache
+func (m *Memory) DeletePrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, elem := range m.items {
+		if strings.HasPrefix(key, prefix) {
+			m.remove(elem)
+		}
+	}
+}
+
+// Stats 实现 Cache
+func (m *Memory) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return Stats{
+		Driver:    "memory",
+		Hits:      m.hits,
+		Misses:    m.misses,
+		Evictions: m.evictions,
+		Entries:   m.order.Len(),
+		Capacity:  m.capacity,
+	}
+}
+
+// remove 删除条目，需持有锁
+func (m *Memory) remove(elem *list.Element) {
+	m.order.Remove(elem)
+	delete(m.items, elem.Value.(*memoryEntry).key)
+}
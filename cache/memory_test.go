@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryLRU(t *testing.T) {
+	tests := []struct {
+		name      string
+		capacity  int
+		ops       func(m *Memory)
+		present   []string
+		absent    []string
+		evictions uint64
+	}{
+		{
+			name:     "超过容量淘汰最早写入的条目",
+			capacity: 2,
+			ops: func(m *Memory) {
+				m.Set("a", 1)
+				m.Set("b", 2)
+				m.Set("c", 3)
+			},
+			present:   []string{"b", "c"},
+			absent:    []string{"a"},
+			evictions: 1,
+		},
+		{
+			name:     "读取后的条目不被淘汰",
+			capacity: 2,
+			ops: func(m *Memory) {
+				m.Set("a", 1)
+				m.Set("b", 2)
+				m.Get("a")
+				m.Set("c", 3)
+			},
+			present:   []string{"a", "c"},
+			absent:    []string{"b"},
+			evictions: 1,
+		},
+		{
+			name:     "覆盖写入不占用容量",
+			capacity: 2,
+			ops: func(m *Memory) {
+				m.Set("a", 1)
+				m.Set("b", 2)
+				m.Set("a", 10)
+				m.Set("c", 3)
+			},
+			present:   []string{"a", "c"},
+			absent:    []string{"b"},
+			evictions: 1,
+		},
+		{
+			name:     "主动删除不计入淘汰",
+			capacity: 2,
+			ops: func(m *Memory) {
+				m.Set("a", 1)
+				m.Set("b", 2)
+				m.Delete("a", "missing")
+				m.Set("c", 3)
+			},
+			present: []string{"b", "c"},
+			absent:  []string{"a"},
+		},
+		{
+			name:     "按前缀删除",
+			capacity: 10,
+			ops: func(m *Memory) {
+				m.Set("article:1", 1)
+				m.Set("article:slug:a", 2)
+				m.Set("articles:latest", 3)
+				m.DeletePrefix("article:")
+			},
+			present: []string{"articles:latest"},
+			absent:  []string{"article:1", "article:slug:a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(tt.capacity, 0)
+			tt.ops(m)
+			for _, key := range tt.present {
+				if _, ok := m.Get(key); !ok {
+					t.Errorf("%s 应在缓存中", key)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := m.Get(key); ok {
+					t.Errorf("%s 不应在缓存中", key)
+				}
+			}
+			if stats := m.Stats(); stats.Evictions != tt.evictions {
+				t.Errorf("淘汰数 %d，期望 %d", stats.Evictions, tt.evictions)
+			}
+		})
+	}
+}
+
+func TestMemoryOverwrite(t *testing.T) {
+	m := NewMemory(2, 0)
+	m.Set("a", 1)
+	m.Set("a", 2)
+	if value, _ := m.Get("a"); value != 2 {
+		t.Errorf("a = %v，期望 2", value)
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		wait time.Duration
+		want bool
+	}{
+		{"未过期", time.Hour, 0, true},
+		{"已过期", 10 * time.Millisecond, 30 * time.Millisecond, false},
+		{"ttl为0时不过期", 0, 30 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(10, tt.ttl)
+			m.Set("a", 1)
+			time.Sleep(tt.wait)
+			if _, ok := m.Get("a"); ok != tt.want {
+				t.Errorf("命中 %t，期望 %t", ok, tt.want)
+			}
+			// 过期的条目在读取时删除
+			if entries := m.Stats().Entries; (entries == 1) != tt.want {
+				t.Errorf("条目数 %d，期望命中时为1、过期时为0", entries)
+			}
+		})
+	}
+}
+
+func TestMemoryStats(t *testing.T) {
+	m := NewMemory(3, 0)
+	for i := 0; i < 5; i++ {
+		m.Set(fmt.Sprint(i), i)
+	}
+	m.Get("4")
+	m.Get("0")
+
+	want := Stats{Driver: "memory", Hits: 1, Misses: 1, Evictions: 2, Entries: 3, Capacity: 3}
+	if got := m.Stats(); got != want {
+		t.Errorf("Stats = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestNoop(t *testing.T) {
+	n := NewNoop()
+	n.Set("a", 1)
+	if _, ok := n.Get("a"); ok {
+		t.Error("Noop 不应命中")
+	}
+	want := Stats{Driver: "none", Misses: 1}
+	if got := n.Stats(); got != want {
+		t.Errorf("Stats = %+v，期望 %+v", got, want)
+	}
+}
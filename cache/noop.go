@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"sync/atomic"
+)
+
+// Noop 不缓存任何数据，只统计未命中次数，用于关闭缓存和命令行子命令
+type Noop struct {
+	misses atomic.Uint64
+}
+
+// NewNoop 创建不缓存的实现
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+// Get 实现 Cache，总是未命中
+func (n *Noop) Get(key string) (interface{}, bool) {
+	n.misses.Add(1)
+	return nil, false
+}
+
+// Set 实现 Cache
+func (n *Noop) Set(key string, value interface{}) {}
+
+// Delete 实现 Cache
+func (n *Noop) Delete(keys ...string) {}
+
+// DeletePrefix 实现 Cache
+func (n *Noop) DeletePrefix(prefix string) {}
+
+// Stats 实现 Cache
+func (n *Noop) Stats() Stats {
+	return Stats{Driver: "none", Misses: n.misses.Load()}
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"flag"
+	"log"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/services"
+)
+
+// cleanupMedia 清理未被文章引用、也未用作头像的上传文件: cleanup-media [-dry-run]
+func cleanupMedia(args []string) error {
+	flags := flag.NewFlagSet("cleanup-media", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "只列出待清理的文件，不删除")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	media, err := services.CleanupOrphanMedia(*dryRun)
+	for _, m := range media {
+		log.Printf("未引用文件: %s（%s，%d 字节，上传于 %s）\n", m.Key, m.Filename, m.Size, m.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		log.Printf("共 %d 个超过 %d 小时未被引用的文件，未删除\n", len(media), config.AppConfig.Upload.OrphanTTL)
+	} else {
+		log.Printf("已清理 %d 个超过 %d 小时未被引用的文件\n", len(media), config.AppConfig.Upload.OrphanTTL)
+	}
+	return nil
+}
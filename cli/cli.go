@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"fmt"
+)
+
+// Run 执行命令行子命令
+func Run(name string, args []string) error {
+	switch name {
+	case "export-static":
+		return exportStatic(args)
+	case "import":
+		return importMarkdown(args)
+	case "export":
+		return exportMarkdown(args)
+	case "import-wordpress":
+		return importWordPress(args)
+	case "cleanup-media":
+		return cleanupMedia(args)
+	case "process-media":
+		return processMedia(args)
+	case "set-role":
+		return setRole(args)
+	case "purge-accounts":
+		return purgeAccounts(args)
+	case "purge-trash":
+		return purgeTrash(args)
+	default:
+		return fmt.Errorf("未知命令: %s", name)
+	}
+}
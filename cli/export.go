@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/dingdinglz/test-blog/services"
+)
+
+// exportMarkdown 导出为带frontmatter的Markdown文件: export [-dir ./content] [-jekyll]
+func exportMarkdown(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dir := fs.String("dir", "./content", "输出目录")
+	jekyll := fs.Bool("jekyll", false, "使用Jekyll的文件名格式 YYYY-MM-DD-slug.md")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	articles, err := services.GetExportArticles()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	for i := range articles {
+		article := &articles[i]
+		data, err := services.ExportMarkdown(article)
+		if err != nil {
+			return err
+		}
+
+		name := article.Slug + ".md"
+		if *jekyll {
+			name = article.CreatedAt.Format("2006-01-02") + "-" + name
+		}
+		if err := os.WriteFile(filepath.Join(*dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("导出完成: %s，共 %d 篇文章\n", *dir, len(articles))
+	return nil
+}
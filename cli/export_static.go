@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"flag"
+	"log"
+
+	"github.com/dingdinglz/test-blog/web"
+)
+
+// exportStatic 导出静态站点: export-static [-out ./public] [-force]
+func exportStatic(args []string) error {
+	fs := flag.NewFlagSet("export-static", flag.ContinueOnError)
+	out := fs.String("out", "./public", "输出目录")
+	force := fs.Bool("force", false, "忽略导出清单，重新渲染所有文章页面")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	stats, err := web.ExportStatic(*out, *force)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("静态站点导出完成: %s，渲染文章 %d 篇，跳过未修改 %d 篇，删除过期文件 %d 个，跳转页面 %d 个\n",
+		*out, stats.Rendered, stats.Skipped, stats.Removed, stats.Redirects)
+	return nil
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+// jekyllFilename Jekyll文章文件名中的日期前缀，如 2024-01-02-hello-world.md
+var jekyllFilename = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+// importFormats 可导入的文件扩展名及其内容格式
+var importFormats = map[string]string{
+	".md":       models.ContentFormatMarkdown,
+	".markdown": models.ContentFormatMarkdown,
+	".html":     models.ContentFormatHTML,
+	".txt":      models.ContentFormatPlain,
+}
+
+// importMarkdown 导入Markdown文件: import [-dir ./content] [-author 用户名]
+func importMarkdown(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dir := flags.String("dir", "./content", "Markdown文件目录，包含子目录")
+	author := flags.String("author", "", "frontmatter未指定作者或作者不存在时使用的用户名")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	failed := 0
+
+	err := filepath.WalkDir(*dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), "_") || importFormats[strings.ToLower(filepath.Ext(path))] == "" {
+			return nil
+		}
+
+		doc, err := readDocument(path)
+		if err == nil {
+			var result string
+			result, _, err = services.ImportArticle(*doc, *author)
+			if err == nil {
+				counts[result]++
+				return nil
+			}
+		}
+
+		failed++
+		log.Printf("导入失败 %s: %v\n", path, err)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("导入完成: 新建 %d 篇，更新 %d 篇，未变化 %d 篇，失败 %d 篇\n",
+		counts[services.ImportCreated], counts[services.ImportUpdated], counts[services.ImportUnchanged], failed)
+	if failed > 0 {
+		return fmt.Errorf("%d 个文件导入失败", failed)
+	}
+	return nil
+}
+
+// readDocument 解析单个文件，slug依次取frontmatter、文件名（Hugo页面包取目录名）、标题
+func readDocument(path string) (*services.ImportDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, body, err := utils.ParseFrontmatter(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext)
+	if name == "index" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+	date := meta.Date
+	if m := jekyllFilename.FindStringSubmatch(name); m != nil {
+		name = m[2]
+		if date == "" {
+			date = m[1]
+		}
+	}
+
+	if meta.Title == "" {
+		return nil, errors.New("frontmatter缺少title")
+	}
+
+	doc := &services.ImportDocument{
+		Title:         meta.Title,
+		Slug:          meta.Slug,
+		Author:        meta.AuthorName(),
+		Content:       body,
+		ContentFormat: meta.Format,
+		Tags:          append(append([]string{}, meta.Tags...), meta.Categories...),
+		Status:        meta.Status,
+		Excerpt:       meta.ExcerptText(),
+		CoverImage:    meta.CoverImage(),
+	}
+	if doc.CoverImage != "" && !utils.ValidImageURL(doc.CoverImage) {
+		// Hugo和Jekyll常用相对路径引用图片，导入后无法访问
+		log.Printf("忽略 %s 的封面图片 %s: 不是http(s)地址或站内路径\n", path, doc.CoverImage)
+		doc.CoverImage = ""
+	}
+	if doc.ContentFormat == "" {
+		doc.ContentFormat = importFormats[strings.ToLower(ext)]
+	}
+	if doc.Slug == "" {
+		doc.Slug = strings.ToLower(name)
+		if !utils.ValidSlug(doc.Slug) {
+			doc.Slug = utils.Slugify(meta.Title)
+		}
+	}
+
+	// 草稿状态: status字段优先，其次是Hugo的draft和Jekyll的published
+	if doc.Status == "" && (meta.Draft || (meta.Published != nil && !*meta.Published)) {
+		doc.Status = models.ArticleStatusDraft
+	}
+	if doc.Status != "" && doc.Status != models.ArticleStatusDraft && doc.Status != models.ArticleStatusPublished {
+		return nil, fmt.Errorf("未知的status: %s", doc.Status)
+	}
+
+	if date != "" {
+		if doc.CreatedAt, err = utils.ParseDate(date); err != nil {
+			return nil, err
+		}
+	}
+	for _, updated := range []string{meta.LastMod, meta.Updated} {
+		if updated != "" {
+			if doc.UpdatedAt, err = utils.ParseDate(updated); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dingdinglz/test-blog/services"
+)
+
+// progressInterval 每处理多少个item输出一次进度
+const progressInterval = 100
+
+// importWordPress 导入WordPress导出文件: import-wordpress -file export.xml [-author 用户名] [-dry-run]
+func importWordPress(args []string) error {
+	flags := flag.NewFlagSet("import-wordpress", flag.ContinueOnError)
+	file := flags.String("file", "", "WordPress导出的WXR文件")
+	author := flags.String("author", "", "文章作者不在导出文件中时使用的用户名")
+	dryRun := flags.Bool("dry-run", false, "只输出导入报告，不写入数据库")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("请使用 -file 指定WXR文件")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	report, err := services.ImportWordPress(f, services.WordPressOptions{
+		DryRun:        *dryRun,
+		DefaultAuthor: *author,
+		Progress: func(items int, offset int64) {
+			if items%progressInterval == 0 && info.Size() > 0 {
+				log.Printf("已处理 %d 项 (%.1f%%)\n", items, float64(offset)*100/float64(info.Size()))
+			}
+		},
+	})
+	if report != nil {
+		printWordPressReport(report, *dryRun)
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d 项导入失败，修正后重新执行即可继续导入", report.Failed)
+	}
+	return nil
+}
+
+// printWordPressReport 输出导入报告
+func printWordPressReport(report *services.WordPressReport, dryRun bool) {
+	title := "WordPress导入报告"
+	if dryRun {
+		title += "（试运行，未写入数据库）"
+	}
+
+	fmt.Printf("%s: %s\n", title, report.Source)
+	fmt.Printf("  处理项目: %d\n", report.Items)
+	fmt.Printf("  用户: 新建 %d，使用已有 %d\n", report.UsersCreated, report.UsersExisting)
+	fmt.Printf("  文章: 新建 %d（其中草稿 %d），之前已导入 %d\n", report.ArticlesCreated, report.ArticlesDraft, report.ArticlesSkipped)
+	fmt.Printf("  评论: 新建 %d（其中待审核 %d），之前已导入 %d\n", report.CommentsCreated, report.CommentsPending, report.CommentsSkipped)
+	fmt.Printf("  标签: %d\n", report.Tags)
+	fmt.Printf("  旧地址重定向: %d\n", report.Redirects)
+
+	if len(report.Ignored) > 0 {
+		kinds := make([]string, 0, len(report.Ignored))
+		for kind := range report.Ignored {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		parts := make([]string, 0, len(kinds))
+		for _, kind := range kinds {
+			parts = append(parts, fmt.Sprintf("%s %d", kind, report.Ignored[kind]))
+		}
+		fmt.Printf("  未导入: %s\n", strings.Join(parts, "，"))
+	}
+
+	fmt.Printf("  失败: %d\n", report.Failed)
+	for _, warning := range report.Warnings {
+		fmt.Printf("  警告: %s\n", warning)
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"flag"
+	"log"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+)
+
+// processMedia 生成上传图片的缩放版本: process-media [-all]
+func processMedia(args []string) error {
+	flags := flag.NewFlagSet("process-media", flag.ContinueOnError)
+	all := flags.Bool("all", false, "重新处理所有文件，用于修改 image 配置后重新生成缩放版本")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	failed := 0
+	total, err := services.ReprocessMedia(*all, func(media *models.Media, err error) {
+		if err != nil {
+			failed++
+			log.Printf("处理失败: %s（%s）: %v\n", media.Key, media.Filename, err)
+			return
+		}
+		log.Printf("已处理: %s（%s）\n", media.Key, media.Filename)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("共处理 %d 个文件，失败 %d 个\n", total, failed)
+	return nil
+}
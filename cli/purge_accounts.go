@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"flag"
+	"log"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/services"
+)
+
+// purgeAccounts 永久删除已过注销等待期的账户: purge-accounts [-dry-run]
+func purgeAccounts(args []string) error {
+	flags := flag.NewFlagSet("purge-accounts", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "只列出待注销的账户，不删除")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	users, err := services.PurgeAccounts(*dryRun)
+	for _, user := range users {
+		log.Printf("注销账户: %s（计划删除时间 %s）\n", user.Username, user.DeleteAt.Format("2006-01-02 15:04:05"))
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		log.Printf("共 %d 个待注销的账户，未删除\n", len(users))
+	} else {
+		log.Printf("已注销 %d 个账户，内容处理方式: %s\n", len(users), config.AppConfig.Account.DeletionMode)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"flag"
+	"log"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/services"
+)
+
+// purgeTrash 永久删除回收站中过期的文章: purge-trash [-dry-run]
+func purgeTrash(args []string) error {
+	flags := flag.NewFlagSet("purge-trash", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "只列出待删除的文章，不删除")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	articles, err := services.PurgeExpiredTrash(*dryRun)
+	if err != nil {
+		return err
+	}
+	for _, article := range articles {
+		log.Printf("过期文章: %s（ID %d，删除于 %s）\n", article.Title, article.ID, article.DeletedAt.Time.Format("2006-01-02 15:04:05"))
+	}
+
+	if *dryRun {
+		log.Printf("共 %d 篇在回收站中超过 %d 天的文章，未删除\n", len(articles), config.AppConfig.Trash.RetentionDays)
+	} else {
+		log.Printf("已永久删除 %d 篇在回收站中超过 %d 天的文章\n", len(articles), config.AppConfig.Trash.RetentionDays)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+)
+
+// setRole 设置用户角色: set-role -user <用户名> [-role admin|user]
+func setRole(args []string) error {
+	flags := flag.NewFlagSet("set-role", flag.ContinueOnError)
+	username := flags.String("user", "", "用户名")
+	role := flags.String("role", models.UserRoleAdmin, "角色，admin 或 user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("缺少 -user 参数")
+	}
+
+	if err := services.SetUserRole(*username, *role); err != nil {
+		return err
+	}
+
+	log.Printf("已将用户 %s 的角色设置为 %s\n", *username, *role)
+	return nil
+}
@@ -8,10 +8,24 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	CORS     CORSConfig     `mapstructure:"cors"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	CORS        CORSConfig        `mapstructure:"cors"`
+	I18n        I18nConfig        `mapstructure:"i18n"`
+	Site        SiteConfig        `mapstructure:"site"`
+	Feed        FeedConfig        `mapstructure:"feed"`
+	Robots      RobotsConfig      `mapstructure:"robots"`
+	Theme       ThemeConfig       `mapstructure:"theme"`
+	Upload      UploadConfig      `mapstructure:"upload"`
+	Image       ImageConfig       `mapstructure:"image"`
+	Avatar      AvatarConfig      `mapstructure:"avatar"`
+	Account     AccountConfig     `mapstructure:"account"`
+	Trash       TrashConfig       `mapstructure:"trash"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	HTTPCache   HTTPCacheConfig   `mapstructure:"http_cache"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	Compression CompressionConfig `mapstructure:"compression"`
 }
 
 // ServerConfig 服务器配置
@@ -36,6 +50,135 @@ type CORSConfig struct {
 	AllowOrigins []string `mapstructure:"allow_origins"`
 }
 
+// I18nConfig 多语言配置
+type I18nConfig struct {
+	DefaultLocale string `mapstructure:"default_locale"`
+	LocalesDir    string `mapstructure:"locales_dir"`
+}
+
+// SiteConfig 站点信息配置
+type SiteConfig struct {
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+	BaseURL     string `mapstructure:"base_url"`
+}
+
+// FeedConfig 订阅源配置
+type FeedConfig struct {
+	Mode          string `mapstructure:"mode"` // full 输出全文，summary 只输出摘要
+	Limit         int    `mapstructure:"limit"`
+	SummaryLength int    `mapstructure:"summary_length"`
+}
+
+// RobotsConfig robots.txt配置
+type RobotsConfig struct {
+	Allow    []string `mapstructure:"allow"`
+	Disallow []string `mapstructure:"disallow"`
+}
+
+// ThemeConfig 页面主题配置
+type ThemeConfig struct {
+	Name     string `mapstructure:"name"`
+	Dir      string `mapstructure:"dir"`
+	PageSize int    `mapstructure:"page_size"`
+}
+
+// UploadConfig 文件上传配置
+type UploadConfig struct {
+	Storage      string             `mapstructure:"storage"` // local 或 s3
+	MaxSizeMB    int64              `mapstructure:"max_size_mb"`
+	AllowedTypes []string           `mapstructure:"allowed_types"`
+	OrphanTTL    int                `mapstructure:"orphan_ttl"` // 未被文章引用的文件保留的小时数
+	Local        LocalStorageConfig `mapstructure:"local"`
+	S3           S3StorageConfig    `mapstructure:"s3"`
+}
+
+// LocalStorageConfig 本地磁盘存储配置
+type LocalStorageConfig struct {
+	Dir       string `mapstructure:"dir"`
+	URLPrefix string `mapstructure:"url_prefix"`
+}
+
+// S3StorageConfig S3兼容对象存储配置
+type S3StorageConfig struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
+	PublicURL string `mapstructure:"public_url"` // 文件的公开访问地址前缀，如CDN域名
+}
+
+// ImageConfig 上传图片的处理配置
+type ImageConfig struct {
+	Workers   int                  `mapstructure:"workers"`
+	Quality   int                  `mapstructure:"quality"`    // JPEG质量，1-100
+	WebP      bool                 `mapstructure:"webp"`       // 是否为每个尺寸额外生成WebP版本
+	MaxPixels int64                `mapstructure:"max_pixels"` // 图片宽高之积的上限，超过时拒绝上传，避免解码耗尽内存
+	Variants  []ImageVariantConfig `mapstructure:"variants"`
+}
+
+// ImageVariantConfig 图片缩放尺寸
+type ImageVariantConfig struct {
+	Name   string `mapstructure:"name"`
+	Width  int    `mapstructure:"width"`
+	Height int    `mapstructure:"height"` // 不为0时居中裁剪为固定尺寸，为0时按宽度等比缩放
+}
+
+// AvatarConfig 默认头像配置，用户未设置头像时根据邮箱哈希生成Gravatar风格的头像地址
+type AvatarConfig struct {
+	Gravatar string `mapstructure:"gravatar"` // 头像服务地址前缀，为空时不生成默认头像
+	Default  string `mapstructure:"default"`  // 邮箱没有头像时的默认样式，如 identicon、mp、retro
+	Size     int    `mapstructure:"size"`
+}
+
+// AccountConfig 账户注销配置
+type AccountConfig struct {
+	DeletionGraceDays int    `mapstructure:"deletion_grace_days"` // 申请注销后可以撤销的天数，到期后自动或由 purge-accounts 命令处理
+	DeletionMode      string `mapstructure:"deletion_mode"`       // delete 删除全部内容，anonymize 保留文章和评论并转移给匿名用户
+	GhostUsername     string `mapstructure:"ghost_username"`      // anonymize 模式下接收内容的匿名用户，不能用于注册
+	PurgeInterval     int    `mapstructure:"purge_interval"`      // 服务运行时处理到期账户的间隔小时数，为0时只能通过 purge-accounts 命令处理
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	RetentionDays int `mapstructure:"retention_days"` // 删除的文章在回收站中保留的天数，超过后永久删除
+	PurgeInterval int `mapstructure:"purge_interval"` // 服务运行时清理回收站的间隔小时数，为0时只能通过 purge-trash 命令清理
+}
+
+// IdempotencyConfig 幂等键配置
+type IdempotencyConfig struct {
+	TTL int `mapstructure:"ttl"` // 保存请求响应的小时数，过期后相同的键视为新请求
+}
+
+// HTTPCacheConfig 公开接口的HTTP缓存配置
+type HTTPCacheConfig struct {
+	Rules []HTTPCacheRule `mapstructure:"rules"`
+}
+
+// HTTPCacheRule 路由的 Cache-Control 响应头，只用于未登录的GET请求
+type HTTPCacheRule struct {
+	Route        string `mapstructure:"route"` // 路由定义，如 /api/articles/:id
+	CacheControl string `mapstructure:"cache_control"`
+}
+
+// CacheConfig 文章读缓存配置
+type CacheConfig struct {
+	Driver string `mapstructure:"driver"` // memory 或 none
+	Size   int    `mapstructure:"size"`   // 最多缓存的条目数，单篇文章和每种文章列表各占一条
+	TTL    int    `mapstructure:"ttl"`    // 条目的过期秒数，为0时只在数据修改时清除
+}
+
+// CompressionConfig 响应压缩配置
+type CompressionConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	Encodings []string `mapstructure:"encodings"`  // 支持的编码，按优先顺序：br、zstd、gzip
+	MinSize   int      `mapstructure:"min_size"`   // 响应体小于该字节数时不压缩
+	Types     []string `mapstructure:"types"`      // 压缩的Content-Type，不含参数
+	CacheSize int      `mapstructure:"cache_size"` // 订阅源和站点地图预压缩结果的缓存条目数，为0时不缓存
+}
+
 var AppConfig *Config
 
 // LoadConfig 加载配置文件
@@ -52,6 +195,64 @@ func LoadConfig() error {
 	viper.SetDefault("jwt.secret", "your-secret-key-change-this")
 	viper.SetDefault("jwt.expire", 168) // 7天
 	viper.SetDefault("cors.allow_origins", []string{"*"})
+	viper.SetDefault("i18n.default_locale", "zh-CN")
+	viper.SetDefault("i18n.locales_dir", "./locales")
+	viper.SetDefault("site.title", "test-blog")
+	viper.SetDefault("site.description", "")
+	viper.SetDefault("site.base_url", "http://localhost:8080")
+	viper.SetDefault("feed.mode", "full")
+	viper.SetDefault("feed.limit", 20)
+	viper.SetDefault("feed.summary_length", 200)
+	viper.SetDefault("robots.allow", []string{})
+	viper.SetDefault("robots.disallow", []string{"/api/"})
+	viper.SetDefault("theme.name", "default")
+	viper.SetDefault("theme.dir", "./themes")
+	viper.SetDefault("theme.page_size", 10)
+	viper.SetDefault("upload.storage", "local")
+	viper.SetDefault("upload.max_size_mb", 10)
+	viper.SetDefault("upload.allowed_types", []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"})
+	viper.SetDefault("upload.orphan_ttl", 24)
+	viper.SetDefault("upload.local.dir", "./uploads")
+	viper.SetDefault("upload.local.url_prefix", "/uploads")
+	viper.SetDefault("upload.s3.region", "us-east-1")
+	viper.SetDefault("upload.s3.use_ssl", true)
+	viper.SetDefault("image.workers", 2)
+	viper.SetDefault("image.quality", 82)
+	viper.SetDefault("image.webp", true)
+	viper.SetDefault("image.max_pixels", 40000000)
+	viper.SetDefault("image.variants", []map[string]interface{}{
+		{"name": "thumbnail", "width": 150, "height": 150},
+		{"name": "medium", "width": 800},
+		{"name": "large", "width": 1600},
+	})
+	viper.SetDefault("avatar.gravatar", "https://www.gravatar.com/avatar/")
+	viper.SetDefault("avatar.default", "identicon")
+	viper.SetDefault("avatar.size", 80)
+	viper.SetDefault("account.deletion_grace_days", 14)
+	viper.SetDefault("account.deletion_mode", "delete")
+	viper.SetDefault("account.ghost_username", "ghost")
+	viper.SetDefault("account.purge_interval", 1)
+	viper.SetDefault("trash.retention_days", 30)
+	viper.SetDefault("trash.purge_interval", 24)
+	viper.SetDefault("idempotency.ttl", 24)
+	viper.SetDefault("cache.driver", "memory")
+	viper.SetDefault("cache.size", 1000)
+	viper.SetDefault("cache.ttl", 60)
+	viper.SetDefault("compression.enabled", true)
+	viper.SetDefault("compression.encodings", []string{"br", "zstd", "gzip"})
+	viper.SetDefault("compression.min_size", 1024)
+	viper.SetDefault("compression.types", []string{
+		"application/json", "text/html", "text/plain", "text/css", "text/javascript", "application/javascript",
+		"application/xml", "text/xml", "application/rss+xml", "application/atom+xml", "application/feed+json", "image/svg+xml",
+	})
+	viper.SetDefault("compression.cache_size", 64)
+	viper.SetDefault("http_cache.rules", []map[string]interface{}{
+		{"route": "/api/articles", "cache_control": "public, max-age=60"},
+		{"route": "/api/articles/:id", "cache_control": "public, max-age=60"},
+		{"route": "/api/articles/slug/:slug", "cache_control": "public, max-age=60"},
+		{"route": "/api/articles/user/:user_id", "cache_control": "public, max-age=60"},
+		{"route": "/api/tags/:slug/articles", "cache_control": "public, max-age=60"},
+	})
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
@@ -27,7 +27,15 @@ func Init() error {
 	log.Printf("数据库连接成功: %s\n", dbPath)
 
 	// 自动迁移数据表
-	err = DB.AutoMigrate(&models.User{}, &models.Article{})
+	err = DB.AutoMigrate(&models.User{}, &models.Article{}, &models.ArticleSlug{}, &models.ArticleRedirect{}, &models.Tag{}, &models.Comment{}, &models.ImportedItem{}, &models.Media{}, &models.MediaVariant{}, &models.IdempotencyKey{})
+	if err != nil {
+		return err
+	}
+
+	// 文章只记录对作者自己上传文件的引用，清除旧数据中对其他用户文件的引用
+	err = DB.Exec(`DELETE FROM article_media WHERE NOT EXISTS (
+		SELECT 1 FROM articles, media WHERE articles.id = article_media.article_id
+		AND media.id = article_media.media_id AND articles.user_id = media.user_id)`).Error
 	if err != nil {
 		return err
 	}
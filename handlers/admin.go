@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/dingdinglz/test-blog/cache"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCacheStats 获取读缓存的命中统计，仅管理员可用
+func GetCacheStats(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+	if !services.GetViewer(userID.(uint)).Admin {
+		utils.Error(c, utils.ErrForbidden)
+		return
+	}
+
+	stats := cache.GetCache().Stats()
+	response := models.CacheStatsResponse{
+		Driver:    stats.Driver,
+		Hits:      stats.Hits,
+		Misses:    stats.Misses,
+		Evictions: stats.Evictions,
+		Entries:   stats.Entries,
+		Capacity:  stats.Capacity,
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		response.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	utils.Success(c, response, utils.MsgSuccess)
+}
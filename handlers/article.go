@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/dingdinglz/test-blog/models"
@@ -11,82 +12,97 @@ import (
 
 // CreateArticleRequest 创建文章请求
 type CreateArticleRequest struct {
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	Title         string   `json:"title" binding:"required"`
+	Content       string   `json:"content" binding:"required"`
+	ContentFormat string   `json:"content_format" binding:"omitempty,oneof=markdown html plain"`
+	Slug          string   `json:"slug" binding:"omitempty,max=80"`
+	Tags          []string `json:"tags" binding:"omitempty,max=20,dive,required,max=64"`
+	Status        string   `json:"status" binding:"omitempty,oneof=draft published"`
+	CoverImage    *string  `json:"cover_image" binding:"omitempty,max=512"`
+	Excerpt       *string  `json:"excerpt" binding:"omitempty,max=1000"`
 }
 
-// UpdateArticleRequest 更新文章请求
+// UpdateArticleRequest 更新文章请求，也是PATCH请求的目标文档，新增的可编辑字段需同时在 articleDocument 中填充
 type UpdateArticleRequest struct {
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	Title         string   `json:"title" binding:"required"`
+	Content       string   `json:"content" binding:"required"`
+	ContentFormat string   `json:"content_format" binding:"omitempty,oneof=markdown html plain"`
+	Slug          string   `json:"slug" binding:"omitempty,max=80"`
+	Tags          []string `json:"tags" binding:"omitempty,max=20,dive,required,max=64"`
+	Status        string   `json:"status" binding:"omitempty,oneof=draft published"`
+	CoverImage    *string  `json:"cover_image" binding:"omitempty,max=512"`
+	Excerpt       *string  `json:"excerpt" binding:"omitempty,max=1000"`
+	Version       uint     `json:"version"` // 客户端持有的版本号，未传If-Match请求头时必填
+}
+
+// tagResponses 构建标签响应
+func tagResponses(tags []models.Tag) []models.TagResponse {
+	response := make([]models.TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, models.TagResponse{Name: tag.Name, Slug: tag.Slug})
+	}
+	return response
 }
 
 // Create 创建文章
 func CreateArticle(c *gin.Context) {
 	var req CreateArticleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Error(c, 400, "参数错误: "+err.Error())
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, true)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
 	// 从Context获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.Error(c, 401, "未授权")
+		utils.Error(c, utils.ErrUnauthorized)
 		return
 	}
 
 	// 调用服务层
-	article, err := services.CreateArticle(req.Title, req.Content, userID.(uint))
+	article, err := services.CreateArticle(services.ArticleInput{
+		Title:         req.Title,
+		Content:       req.Content,
+		ContentFormat: req.ContentFormat,
+		Slug:          req.Slug,
+		Tags:          req.Tags,
+		Status:        req.Status,
+		CoverImage:    req.CoverImage,
+		Excerpt:       req.Excerpt,
+	}, userID.(uint))
 	if err != nil {
-		utils.Error(c, 500, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
 	// 返回响应
-	utils.Success(c, models.ArticleResponse{
-		ID:      article.ID,
-		Title:   article.Title,
-		Content: article.Content,
-		UserID:  article.UserID,
-		Author: models.UserResponse{
-			ID:       article.User.ID,
-			Username: article.User.Username,
-			Email:    article.User.Email,
-		},
-		CreatedAt: article.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: article.UpdatedAt.Format("2006-01-02 15:04:05"),
-	}, "创建成功")
+	respondArticle(c, view, article, utils.MsgCreated)
 }
 
 // GetAll 获取所有文章
 func GetAllArticles(c *gin.Context) {
-	// 调用服务层
-	articles, err := services.GetAllArticles()
+	// 解析返回的字段
+	view, err := parseArticleView(c, false)
 	if err != nil {
-		utils.Error(c, 500, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
-	// 构建响应
-	var response []models.ArticleResponse
-	for _, article := range articles {
-		response = append(response, models.ArticleResponse{
-			ID:      article.ID,
-			Title:   article.Title,
-			Content: article.Content,
-			UserID:  article.UserID,
-			Author: models.UserResponse{
-				ID:       article.User.ID,
-				Username: article.User.Username,
-				Email:    article.User.Email,
-			},
-			CreatedAt: article.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt: article.UpdatedAt.Format("2006-01-02 15:04:05"),
-		})
+	// 调用服务层
+	articles, err := services.GetAllArticles(view.load())
+	if err != nil {
+		utils.Error(c, err)
+		return
 	}
 
-	utils.Success(c, response, "success")
+	respondArticles(c, view, articles)
 }
 
 // GetByUser 获取指定用户的文章
@@ -95,36 +111,51 @@ func GetArticlesByUser(c *gin.Context) {
 	userIDStr := c.Param("user_id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		utils.Error(c, 400, "无效的用户ID")
+		utils.Error(c, utils.ErrInvalidID)
+		return
+	}
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, false)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
 	// 调用服务层
-	articles, err := services.GetUserArticles(uint(userID))
+	articles, err := services.GetUserArticles(uint(userID), view.load())
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	respondArticles(c, view, articles)
+}
+
+// GetMyArticles 获取当前用户的全部文章，包括草稿
+func GetMyArticles(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, false)
 	if err != nil {
-		utils.Error(c, 500, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
-	// 构建响应
-	var response []models.ArticleResponse
-	for _, article := range articles {
-		response = append(response, models.ArticleResponse{
-			ID:      article.ID,
-			Title:   article.Title,
-			Content: article.Content,
-			UserID:  article.UserID,
-			Author: models.UserResponse{
-				ID:       article.User.ID,
-				Username: article.User.Username,
-				Email:    article.User.Email,
-			},
-			CreatedAt: article.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt: article.UpdatedAt.Format("2006-01-02 15:04:05"),
-		})
+	// 调用服务层
+	articles, err := services.GetOwnArticles(userID.(uint), view.load())
+	if err != nil {
+		utils.Error(c, err)
+		return
 	}
 
-	utils.Success(c, response, "success")
+	respondArticles(c, view, articles)
 }
 
 // GetArticleByID 根据ID获取文章
@@ -133,33 +164,71 @@ func GetArticleByID(c *gin.Context) {
 	articleIDStr := c.Param("id")
 	articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
 	if err != nil {
-		utils.Error(c, 400, "无效的文章ID")
+		utils.Error(c, utils.ErrInvalidID)
+		return
+	}
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, true)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
 	// 调用服务层
 	article, err := services.GetArticleByID(uint(articleID))
 	if err != nil {
-		utils.Error(c, 404, err.Error())
+		utils.Error(c, err)
+		return
+	}
+
+	// 草稿只对作者本人可见
+	if !services.ArticleVisible(article, c.GetUint("user_id")) {
+		utils.Error(c, services.ErrArticleNotFound)
+		return
+	}
+
+	respondArticle(c, view, article, utils.MsgSuccess)
+}
+
+// GetArticleBySlug 根据slug获取文章，历史slug永久重定向到当前slug
+func GetArticleBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, true)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 调用服务层
+	article, err := services.GetArticleBySlug(slug)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
-	// 构建响应
-	response := models.ArticleResponse{
-		ID:      article.ID,
-		Title:   article.Title,
-		Content: article.Content,
-		UserID:  article.UserID,
-		Author: models.UserResponse{
-			ID:       article.User.ID,
-			Username: article.User.Username,
-			Email:    article.User.Email,
-		},
-		CreatedAt: article.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: article.UpdatedAt.Format("2006-01-02 15:04:05"),
+	// 草稿只对作者本人可见
+	if !services.ArticleVisible(article, c.GetUint("user_id")) {
+		utils.Error(c, services.ErrArticleNotFound)
+		return
 	}
 
-	utils.Success(c, response, "success")
+	if article.Slug != slug {
+		redirectSlug(c, "/api/articles/slug/"+article.Slug)
+		return
+	}
+
+	respondArticle(c, view, article, utils.MsgSuccess)
+}
+
+// redirectSlug 历史slug永久重定向到当前地址，保留查询参数
+func redirectSlug(c *gin.Context, path string) {
+	if c.Request.URL.RawQuery != "" {
+		path += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, path)
 }
 
 // Update 更新文章
@@ -168,44 +237,46 @@ func UpdateArticle(c *gin.Context) {
 	articleIDStr := c.Param("id")
 	articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
 	if err != nil {
-		utils.Error(c, 400, "无效的文章ID")
+		utils.Error(c, utils.ErrInvalidID)
 		return
 	}
 
 	var req UpdateArticleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Error(c, 400, "参数错误: "+err.Error())
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, true)
+	if err != nil {
+		utils.Error(c, err)
 		return
 	}
 
 	// 从Context获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.Error(c, 401, "未授权")
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 获取客户端持有的版本号
+	version, err := requestVersion(c, req.Version)
+	if err != nil {
+		respondArticleError(c, view, uint(articleID), err)
 		return
 	}
 
 	// 调用服务层
-	article, err := services.UpdateArticle(uint(articleID), userID.(uint), req.Title, req.Content)
+	article, err := services.UpdateArticle(uint(articleID), userID.(uint), version, req.input())
 	if err != nil {
-		utils.Error(c, 403, err.Error())
+		respondArticleError(c, view, uint(articleID), err)
 		return
 	}
 
 	// 返回响应
-	utils.Success(c, models.ArticleResponse{
-		ID:      article.ID,
-		Title:   article.Title,
-		Content: article.Content,
-		UserID:  article.UserID,
-		Author: models.UserResponse{
-			ID:       article.User.ID,
-			Username: article.User.Username,
-			Email:    article.User.Email,
-		},
-		CreatedAt: article.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: article.UpdatedAt.Format("2006-01-02 15:04:05"),
-	}, "更新成功")
+	respondArticle(c, view, article, utils.MsgUpdated)
 }
 
 // Delete 删除文章
@@ -214,23 +285,38 @@ func DeleteArticle(c *gin.Context) {
 	articleIDStr := c.Param("id")
 	articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
 	if err != nil {
-		utils.Error(c, 400, "无效的文章ID")
+		utils.Error(c, utils.ErrInvalidID)
 		return
 	}
 
 	// 从Context获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.Error(c, 401, "未授权")
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 解析冲突时返回的字段
+	view, err := parseArticleView(c, true)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 获取客户端持有的版本号，DELETE请求没有请求体，使用 ?version= 参数
+	queryVersion, _ := strconv.ParseUint(c.Query("version"), 10, 32)
+	version, err := requestVersion(c, uint(queryVersion))
+	if err != nil {
+		respondArticleError(c, view, uint(articleID), err)
 		return
 	}
 
 	// 调用服务层
-	err = services.DeleteArticle(uint(articleID), userID.(uint))
+	err = services.DeleteArticle(uint(articleID), userID.(uint), version)
 	if err != nil {
-		utils.Error(c, 403, err.Error())
+		respondArticleError(c, view, uint(articleID), err)
 		return
 	}
 
-	utils.Success(c, nil, "删除成功")
+	utils.Success(c, nil, utils.MsgDeleted)
 }
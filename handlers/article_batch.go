@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// BatchArticlesRequest 批量操作文章请求
+type BatchArticlesRequest struct {
+	Action   string   `json:"action" binding:"required,oneof=publish unpublish delete add_tags remove_tags set_tags change_author"`
+	IDs      []uint   `json:"ids" binding:"required,min=1,max=500"`
+	Tags     []string `json:"tags" binding:"omitempty,max=20,dive,required,max=64"` // add_tags、remove_tags、set_tags 的标签名
+	AuthorID uint     `json:"author_id"`                                            // change_author 的新作者ID
+	Atomic   bool     `json:"atomic"`                                               // 为true时任一文章失败则全部不生效
+}
+
+// BatchArticles 批量操作文章，非原子执行时部分失败也返回200，逐项结果见results
+func BatchArticles(c *gin.Context) {
+	var req BatchArticlesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	results, err := services.BatchArticles(services.GetViewer(userID.(uint)), services.BatchInput{
+		Action:   req.Action,
+		IDs:      req.IDs,
+		Tags:     req.Tags,
+		AuthorID: req.AuthorID,
+		Atomic:   req.Atomic,
+	})
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	response := models.BatchResponse{Results: make([]models.BatchItemResponse, 0, len(results))}
+	var firstErr error
+	for _, result := range results {
+		item := models.BatchItemResponse{ID: result.ID, Status: "ok"}
+		switch {
+		case result.Skipped:
+			item.Status = "skipped"
+			response.Skipped++
+		case result.Err != nil:
+			appErr, message := utils.ErrorInfo(c, result.Err)
+			item.Status = "failed"
+			item.Error = string(appErr.Code)
+			item.Message = message
+			response.Failed++
+			if firstErr == nil {
+				firstErr = result.Err
+			}
+		default:
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	// 原子执行失败时全部回滚，按失败文章的错误返回
+	if req.Atomic && firstErr != nil {
+		utils.ErrorWithData(c, firstErr, response)
+		return
+	}
+
+	utils.Success(c, response, utils.MsgSuccess)
+}
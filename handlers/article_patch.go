@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// patchRetries If-Match为 * 时补丁基于的文章被并发修改后重新应用补丁的次数
+const patchRetries = 3
+
+// input 转换为服务层的文章输入
+func (r *UpdateArticleRequest) input() services.ArticleInput {
+	return services.ArticleInput{
+		Title:         r.Title,
+		Content:       r.Content,
+		ContentFormat: r.ContentFormat,
+		Slug:          r.Slug,
+		Tags:          r.Tags,
+		Status:        r.Status,
+		CoverImage:    r.CoverImage,
+		Excerpt:       r.Excerpt,
+	}
+}
+
+// articleDocument 文章当前的可编辑字段，作为PATCH请求的目标文档
+func articleDocument(article *models.Article) UpdateArticleRequest {
+	tags := make([]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return UpdateArticleRequest{
+		Title:         article.Title,
+		Content:       article.Content,
+		ContentFormat: article.ContentFormat,
+		Slug:          article.Slug,
+		Tags:          tags,
+		Status:        article.Status,
+		CoverImage:    &article.CoverImage,
+		Excerpt:       &article.Excerpt,
+		Version:       article.Version,
+	}
+}
+
+// applyArticlePatch 把补丁应用到文章的可编辑字段，并按更新文章的规则校验结果；
+// 被删除或设为null的标签、封面和摘要恢复默认值
+func applyArticlePatch(article *models.Article, patch []byte, contentType string) (*UpdateArticleRequest, error) {
+	doc, err := json.Marshal(articleDocument(article))
+	if err != nil {
+		return nil, utils.ErrInternal.Wrap(err)
+	}
+
+	var patched []byte
+	if contentType == utils.JSONPatchType {
+		patched, err = utils.JSONPatch(doc, patch)
+	} else {
+		patched, err = utils.MergePatch(doc, patch)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验补丁后的文档，不允许添加未知字段
+	var req UpdateArticleRequest
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return nil, utils.ErrInvalidParams.Wrap(err)
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, utils.ErrInvalidParams.Wrap(err)
+	}
+
+	empty := ""
+	if req.Tags == nil {
+		req.Tags = []string{}
+	}
+	if req.CoverImage == nil {
+		req.CoverImage = &empty
+	}
+	if req.Excerpt == nil {
+		req.Excerpt = &empty
+	}
+	return &req, nil
+}
+
+// PatchArticle 部分更新文章，支持 JSON Merge Patch (RFC 7396) 和 JSON Patch (RFC 6902)
+func PatchArticle(c *gin.Context) {
+	// 获取文章ID参数
+	articleIDStr := c.Param("id")
+	articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
+	if err != nil {
+		utils.Error(c, utils.ErrInvalidID)
+		return
+	}
+
+	// 根据Content-Type确定补丁格式，application/json按合并补丁处理
+	contentType := c.ContentType()
+	if contentType != utils.MergePatchType && contentType != utils.JSONPatchType && contentType != "application/json" {
+		c.Header("Accept-Patch", utils.MergePatchType+", "+utils.JSONPatchType)
+		utils.Error(c, utils.ErrUnsupportedMedia)
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, true)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 获取客户端持有的版本号，未传If-Match时补丁中必须给出version
+	// （合并补丁中的version字段，或JSON Patch中对 /version 的test、replace操作）
+	hasIfMatch := c.GetHeader("If-Match") != ""
+	if !hasIfMatch && !utils.PatchAsserts(patch, contentType, "version") {
+		utils.Error(c, utils.ErrPreconditionNeeded)
+		return
+	}
+	version, err := requestVersion(c, 0)
+	if hasIfMatch && err != nil {
+		respondArticleError(c, view, uint(articleID), err)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		// 获取要修改的文章
+		article, err := services.GetEditableArticle(uint(articleID), userID.(uint))
+		if err != nil {
+			utils.Error(c, err)
+			return
+		}
+		if version != nil && *version != article.Version {
+			respondArticleError(c, view, article.ID, services.ErrVersionConflict)
+			return
+		}
+		// 未传If-Match时，JSON Patch对version的test操作与If-Match作用相同，版本不一致按版本冲突处理
+		if !hasIfMatch && contentType == utils.JSONPatchType {
+			if value, ok := utils.PatchTestValue(patch, "version"); ok {
+				var tested uint
+				if json.Unmarshal(value, &tested) == nil && tested != article.Version {
+					respondArticleError(c, view, article.ID, services.ErrVersionConflict)
+					return
+				}
+			}
+		}
+
+		req, err := applyArticlePatch(article, patch, contentType)
+		if err != nil {
+			utils.Error(c, err)
+			return
+		}
+
+		// If-Match为 * 时以补丁基于的版本更新，避免覆盖期间的其他修改
+		expected := version
+		if !hasIfMatch {
+			// 补丁后续的操作可能又删除了version
+			if req.Version == 0 {
+				utils.Error(c, utils.ErrPreconditionNeeded)
+				return
+			}
+			expected = &req.Version
+		} else if version == nil {
+			expected = &article.Version
+		}
+
+		// 调用服务层
+		updated, err := services.UpdateArticle(article.ID, userID.(uint), expected, req.input())
+		if err != nil {
+			if errors.Is(err, services.ErrVersionConflict) && hasIfMatch && version == nil && attempt < patchRetries {
+				continue
+			}
+			respondArticleError(c, view, article.ID, err)
+			return
+		}
+
+		// 返回响应
+		respondArticle(c, view, updated, utils.MsgUpdated)
+		return
+	}
+}
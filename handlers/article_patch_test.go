@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPatchArticleVersion(t *testing.T) {
+	s := newServer(t)
+	token := s.register("alice")
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		patch       func(version uint) string
+		status      int
+		errCode     string
+	}{
+		{
+			name:        "合并补丁给出当前版本",
+			contentType: "application/merge-patch+json",
+			patch:       func(v uint) string { return fmt.Sprintf(`{"title":"新标题","version":%d}`, v) },
+			status:      http.StatusOK,
+		},
+		{
+			name:        "合并补丁的版本不一致",
+			contentType: "application/merge-patch+json",
+			patch:       func(v uint) string { return fmt.Sprintf(`{"title":"新标题","version":%d}`, v+1) },
+			status:      http.StatusPreconditionFailed,
+			errCode:     "VERSION_CONFLICT",
+		},
+		{
+			name:        "合并补丁未给出版本",
+			contentType: "application/merge-patch+json",
+			patch:       func(uint) string { return `{"title":"新标题"}` },
+			status:      http.StatusPreconditionRequired,
+			errCode:     "PRECONDITION_REQUIRED",
+		},
+		{
+			name:        "合并补丁版本为null",
+			contentType: "application/merge-patch+json",
+			patch:       func(uint) string { return `{"title":"新标题","version":null}` },
+			status:      http.StatusPreconditionRequired,
+			errCode:     "PRECONDITION_REQUIRED",
+		},
+		{
+			name:        "JSON Patch test当前版本",
+			contentType: "application/json-patch+json",
+			patch: func(v uint) string {
+				return fmt.Sprintf(`[{"op":"test","path":"/version","value":%d},{"op":"replace","path":"/title","value":"新标题"}]`, v)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:        "JSON Patch test版本不一致按版本冲突处理",
+			contentType: "application/json-patch+json",
+			patch: func(v uint) string {
+				return fmt.Sprintf(`[{"op":"test","path":"/version","value":%d},{"op":"replace","path":"/title","value":"新标题"}]`, v+1)
+			},
+			status:  http.StatusPreconditionFailed,
+			errCode: "VERSION_CONFLICT",
+		},
+		{
+			name:        "JSON Patch 其他字段的test不满足",
+			contentType: "application/json-patch+json",
+			patch: func(v uint) string {
+				return fmt.Sprintf(`[{"op":"test","path":"/version","value":%d},{"op":"test","path":"/title","value":"其他"}]`, v)
+			},
+			status:  http.StatusConflict,
+			errCode: "PATCH_TEST_FAILED",
+		},
+		{
+			name:        "JSON Patch 删除版本",
+			contentType: "application/json-patch+json",
+			patch: func(v uint) string {
+				return fmt.Sprintf(`[{"op":"test","path":"/version","value":%d},{"op":"remove","path":"/version"}]`, v)
+			},
+			status:  http.StatusPreconditionRequired,
+			errCode: "PRECONDITION_REQUIRED",
+		},
+		{
+			name:        "JSON Patch 未给出版本",
+			contentType: "application/json-patch+json",
+			patch:       func(uint) string { return `[{"op":"replace","path":"/title","value":"新标题"}]` },
+			status:      http.StatusPreconditionRequired,
+			errCode:     "PRECONDITION_REQUIRED",
+		},
+		{
+			name:        "If-Match过期版本",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"0"`,
+			patch:       func(uint) string { return `{"title":"新标题"}` },
+			status:      http.StatusPreconditionFailed,
+			errCode:     "VERSION_CONFLICT",
+		},
+		{
+			name:        "If-Match为*",
+			contentType: "application/merge-patch+json",
+			ifMatch:     "*",
+			patch:       func(uint) string { return `{"title":"新标题"}` },
+			status:      http.StatusOK,
+		},
+		{
+			name:        "不支持的补丁格式",
+			contentType: "text/plain",
+			patch:       func(uint) string { return `title` },
+			status:      http.StatusUnsupportedMediaType,
+			errCode:     "UNSUPPORTED_MEDIA_TYPE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := s.createArticle(token, tt.name)
+			header := []string{"Content-Type", tt.contentType}
+			if tt.ifMatch != "" {
+				header = append(header, "If-Match", tt.ifMatch)
+			}
+			w := s.request(http.MethodPatch, articlePath(article.ID), token, tt.patch(article.Version), header...)
+			expect(t, w, tt.status, tt.errCode)
+
+			var got testArticle
+			decode(t, w, &got)
+			switch tt.status {
+			case http.StatusOK:
+				if got.Title != "新标题" || got.Version != article.Version+1 {
+					t.Errorf("更新后 %+v，期望标题为新标题、版本为 %d", got, article.Version+1)
+				}
+			case http.StatusPreconditionFailed:
+				// 版本冲突时返回服务器上的当前文章和ETag
+				if got.Version != article.Version || w.Header().Get("ETag") == "" {
+					t.Errorf("冲突响应 %+v ETag %q，期望当前版本 %d", got, w.Header().Get("ETag"), article.Version)
+				}
+			}
+		})
+	}
+}
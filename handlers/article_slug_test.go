@@ -0,0 +1,36 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestOldSlugRedirect(t *testing.T) {
+	s := newServer(t)
+	token := s.register("alice")
+	article := s.createArticle(token, "Old Title")
+
+	patch := fmt.Sprintf(`{"slug":"new-slug","version":%d}`, article.Version)
+	w := s.request(http.MethodPatch, articlePath(article.ID), token, patch, "Content-Type", "application/merge-patch+json")
+	expect(t, w, http.StatusOK, "")
+
+	tests := []struct {
+		name     string
+		path     string
+		location string
+	}{
+		{"接口", "/api/articles/slug/" + article.Slug, "/api/articles/slug/new-slug"},
+		{"接口保留查询参数", "/api/articles/slug/" + article.Slug + "?fields=title,slug", "/api/articles/slug/new-slug?fields=title,slug"},
+		{"页面", "/articles/" + article.Slug, "/articles/new-slug"},
+		{"页面保留查询参数", "/articles/" + article.Slug + "?utm_source=feed", "/articles/new-slug?utm_source=feed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.request(http.MethodGet, tt.path, "", nil)
+			if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != tt.location {
+				t.Errorf("响应 %d Location %q，期望 301 %q", w.Code, w.Header().Get("Location"), tt.location)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestArticleWriteVersion(t *testing.T) {
+	s := newServer(t)
+	token := s.register("alice")
+
+	// ifMatch 根据当前版本号和GET返回的ETag生成If-Match请求头，为空时不发送；
+	// version 为请求体（PUT）或 ?version= 参数（DELETE）中的版本号，0表示不传
+	current := func(v uint) uint { return v }
+	stale := func(v uint) uint { return v + 1 }
+	none := func(uint) uint { return 0 }
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch func(v uint, etag string) string
+		version func(v uint) uint
+		status  int
+		errCode string
+	}{
+		{"PUT 请求体给出当前版本", http.MethodPut, nil, current, http.StatusOK, ""},
+		{"PUT 请求体的版本过期", http.MethodPut, nil, stale, http.StatusPreconditionFailed, "VERSION_CONFLICT"},
+		{"PUT 未给出版本", http.MethodPut, nil, none, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED"},
+		{"PUT If-Match为GET返回的ETag", http.MethodPut, func(_ uint, etag string) string { return etag }, none, http.StatusOK, ""},
+		{"PUT If-Match为弱ETag", http.MethodPut, func(_ uint, etag string) string { return "W/" + etag }, none, http.StatusOK, ""},
+		{"PUT If-Match只有版本号", http.MethodPut, func(v uint, _ string) string { return fmt.Sprintf(`"%d"`, v) }, none, http.StatusOK, ""},
+		// If-Match 优先于请求体中的版本号
+		{"PUT If-Match过期", http.MethodPut, func(v uint, _ string) string { return fmt.Sprintf(`"%d"`, v+1) }, current, http.StatusPreconditionFailed, "VERSION_CONFLICT"},
+		{"PUT If-Match无法解析", http.MethodPut, func(uint, string) string { return "abc" }, current, http.StatusPreconditionFailed, "VERSION_CONFLICT"},
+		{"PUT If-Match为*", http.MethodPut, func(uint, string) string { return "*" }, stale, http.StatusOK, ""},
+		{"DELETE 参数给出当前版本", http.MethodDelete, nil, current, http.StatusOK, ""},
+		{"DELETE 参数的版本过期", http.MethodDelete, nil, stale, http.StatusPreconditionFailed, "VERSION_CONFLICT"},
+		{"DELETE 未给出版本", http.MethodDelete, nil, none, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED"},
+		{"DELETE If-Match为GET返回的ETag", http.MethodDelete, func(_ uint, etag string) string { return etag }, none, http.StatusOK, ""},
+		{"DELETE If-Match为*", http.MethodDelete, func(uint, string) string { return "*" }, none, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := s.createArticle(token, tt.name)
+			path := articlePath(article.ID)
+			etag := s.request(http.MethodGet, path, "", nil).Header().Get("ETag")
+
+			var header []string
+			if tt.ifMatch != nil {
+				header = []string{"If-Match", tt.ifMatch(article.Version, etag)}
+			}
+			version := tt.version(article.Version)
+
+			var w *httptest.ResponseRecorder
+			if tt.method == http.MethodPut {
+				body := map[string]interface{}{"title": "新标题", "content": "新内容", "version": version}
+				w = s.request(http.MethodPut, path, token, body, header...)
+			} else {
+				w = s.request(http.MethodDelete, fmt.Sprintf("%s?version=%d", path, version), token, nil, header...)
+			}
+			expect(t, w, tt.status, tt.errCode)
+
+			// 失败时文章不变，成功时版本号加一或移入回收站
+			var got testArticle
+			after := s.request(http.MethodGet, path, "", nil)
+			switch {
+			case tt.status != http.StatusOK:
+				decode(t, after, &got)
+				if got.Version != article.Version || got.Title != tt.name {
+					t.Errorf("失败后文章 %+v，期望保持版本 %d", got, article.Version)
+				}
+			case tt.method == http.MethodPut:
+				decode(t, after, &got)
+				if got.Version != article.Version+1 || got.Title != "新标题" {
+					t.Errorf("更新后文章 %+v，期望版本 %d", got, article.Version+1)
+				}
+			default:
+				expect(t, after, http.StatusNotFound, "ARTICLE_NOT_FOUND")
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// articleIncludes ?include= 可选的关联和内容
+var articleIncludes = map[string]bool{"author": true, "tags": true, "content": true}
+
+// articleFields ?fields= 可选的字段路径
+var articleFields = utils.JSONPaths(models.ArticleResponse{})
+
+// fieldIncludes 需要通过include加载的响应字段及其对应的include名称
+var fieldIncludes = map[string]string{
+	"author":       "author",
+	"tags":         "tags",
+	"content":      "content",
+	"content_html": "content",
+}
+
+// articleView 文章响应的字段选择，由 ?include= 和 ?fields= 决定
+type articleView struct {
+	include map[string]bool
+	fields  utils.FieldSet
+	viewer  services.Viewer
+}
+
+// parseArticleView 解析字段选择，未传include时列表包含author和tags，详情还包含content；
+// fields中引用的关联会自动加载
+func parseArticleView(c *gin.Context, detail bool) (*articleView, error) {
+	view := &articleView{
+		include: map[string]bool{"author": true, "tags": true, "content": detail},
+		viewer:  services.GetViewer(c.GetUint("user_id")),
+	}
+
+	if raw, ok := c.GetQuery("include"); ok {
+		view.include = map[string]bool{}
+		for _, name := range utils.SplitList(raw) {
+			if !articleIncludes[name] {
+				return nil, utils.ErrInvalidFields
+			}
+			view.include[name] = true
+		}
+	}
+
+	fields, err := utils.ParseFields(c.Query("fields"), articleFields)
+	if err != nil {
+		return nil, utils.ErrInvalidFields.Wrap(err)
+	}
+	view.fields = fields
+
+	for field := range fields {
+		root, _, _ := strings.Cut(field, ".")
+		if name, ok := fieldIncludes[root]; ok {
+			view.include[name] = true
+		}
+	}
+
+	return view, nil
+}
+
+// load 查询文章时需要加载的关联和内容
+func (v *articleView) load() services.ArticleLoad {
+	return services.ArticleLoad{
+		Author:  v.include["author"],
+		Tags:    v.include["tags"],
+		Content: v.include["content"],
+	}
+}
+
+// selected 最终返回的字段，未传fields时为未被include排除的全部顶层字段
+func (v *articleView) selected() utils.FieldSet {
+	if len(v.fields) > 0 {
+		return v.fields
+	}
+
+	fields := utils.FieldSet{}
+	for path := range articleFields {
+		if !strings.Contains(path, ".") {
+			fields[path] = true
+		}
+	}
+	for field, name := range fieldIncludes {
+		if !v.include[name] {
+			delete(fields, field)
+		}
+	}
+	return fields
+}
+
+// render 构建单篇文章的响应
+func (v *articleView) render(article *models.Article) (interface{}, error) {
+	return utils.SelectFields(articleResponse(article, v.viewer), v.selected())
+}
+
+// renderList 构建文章列表的响应
+func (v *articleView) renderList(articles []models.Article) (interface{}, error) {
+	response := make([]models.ArticleResponse, 0, len(articles))
+	for i := range articles {
+		response = append(response, articleResponse(&articles[i], v.viewer))
+	}
+	return utils.SelectFields(response, v.selected())
+}
+
+// articleResponse 构建完整的文章响应
+func articleResponse(article *models.Article, viewer services.Viewer) models.ArticleResponse {
+	return models.ArticleResponse{
+		ID:            article.ID,
+		Title:         article.Title,
+		Slug:          article.Slug,
+		Content:       article.Content,
+		ContentFormat: article.ContentFormat,
+		ContentHTML:   article.ContentHTML,
+		Excerpt:       services.ArticleExcerpt(article),
+		CoverImage:    article.CoverImage,
+		WordCount:     article.WordCount,
+		ReadingTime:   article.ReadingTime,
+		Status:        article.Status,
+		Version:       article.Version,
+		UserID:        article.UserID,
+		Author:        authorResponse(&article.User, viewer),
+		Tags:          tagResponses(article.Tags),
+		CreatedAt:     article.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     article.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// respondArticle 按字段选择返回单篇文章，ETag以文章的版本号开头，Last-Modified为文章或作者资料的更新时间
+func respondArticle(c *gin.Context, view *articleView, article *models.Article, message string) {
+	response, err := view.render(article)
+	if err != nil {
+		utils.Error(c, utils.ErrInternal.Wrap(err))
+		return
+	}
+
+	lastModified := article.UpdatedAt
+	if article.User.UpdatedAt.After(lastModified) {
+		lastModified = article.User.UpdatedAt
+	}
+	utils.SuccessCacheable(c, response, message, func(body []byte) string {
+		return utils.VersionETag(article.Version, body)
+	}, lastModified)
+}
+
+// requestVersion 获取客户端持有的文章版本号，If-Match请求头优先于请求中的version；
+// If-Match为 * 时返回nil表示不检查版本，都没有时返回 ErrPreconditionNeeded
+func requestVersion(c *gin.Context, version uint) (*uint, error) {
+	if header := c.GetHeader("If-Match"); header != "" {
+		v, any, ok := utils.ParseIfMatch(header)
+		if !ok {
+			return nil, services.ErrVersionConflict
+		}
+		if any {
+			return nil, nil
+		}
+		return &v, nil
+	}
+	if version == 0 {
+		return nil, utils.ErrPreconditionNeeded
+	}
+	return &version, nil
+}
+
+// respondArticleError 返回修改文章的错误，版本冲突时附带服务器上的最新文章，便于客户端合并修改
+func respondArticleError(c *gin.Context, view *articleView, articleID uint, err error) {
+	if errors.Is(err, services.ErrVersionConflict) {
+		article, getErr := services.GetArticleByID(articleID)
+		if getErr == nil && services.ArticleVisible(article, view.viewer.ID) {
+			if response, renderErr := view.render(article); renderErr == nil {
+				body, _ := json.Marshal(response)
+				c.Header("ETag", utils.VersionETag(article.Version, body))
+				utils.ErrorWithData(c, err, response)
+				return
+			}
+		}
+	}
+	utils.Error(c, err)
+}
+
+// respondArticles 按字段选择返回文章列表，ETag由响应内容生成；删除文章不会改变列表中文章的更新时间，所以不返回Last-Modified
+func respondArticles(c *gin.Context, view *articleView, articles []models.Article) {
+	response, err := view.renderList(articles)
+	if err != nil {
+		utils.Error(c, utils.ErrInternal.Wrap(err))
+		return
+	}
+	utils.SuccessCacheable(c, response, utils.MsgSuccess, utils.ETag, time.Time{})
+}
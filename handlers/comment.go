@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetArticleComments 获取文章的评论
+func GetArticleComments(c *gin.Context) {
+	// 获取文章ID
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, utils.ErrInvalidID)
+		return
+	}
+
+	// 草稿的评论同样只对作者本人可见
+	article, err := services.GetArticleByID(uint(articleID))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+	if !services.ArticleVisible(article, c.GetUint("user_id")) {
+		utils.Error(c, services.ErrArticleNotFound)
+		return
+	}
+
+	// 调用服务层
+	comments, err := services.GetArticleComments(article.ID)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 构建响应
+	response := []models.CommentResponse{}
+	for _, comment := range comments {
+		response = append(response, models.CommentResponse{
+			ID:         comment.ID,
+			ParentID:   comment.ParentID,
+			UserID:     comment.UserID,
+			AuthorName: comment.AuthorName,
+			AuthorURL:  comment.AuthorURL,
+			Content:    comment.Content,
+			CreatedAt:  comment.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	utils.Success(c, response, utils.MsgSuccess)
+}
@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditionalGet(t *testing.T) {
+	s := newServer(t)
+	token := s.register("alice")
+	article := s.createArticle(token, "缓存")
+	path := articlePath(article.ID)
+
+	w := s.request(http.MethodGet, path, "", nil)
+	expect(t, w, http.StatusOK, "")
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if !strings.HasPrefix(etag, fmt.Sprintf(`"%d-`, article.Version)) || lastModified == "" {
+		t.Fatalf("ETag %q Last-Modified %q，期望以版本号开头的ETag和Last-Modified", etag, lastModified)
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		t.Fatalf("Last-Modified 格式错误: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header []string
+		status int
+	}{
+		{"If-None-Match命中", []string{"If-None-Match", etag}, http.StatusNotModified},
+		{"If-None-Match弱比较", []string{"If-None-Match", `"other", W/` + etag}, http.StatusNotModified},
+		{"If-None-Match为*", []string{"If-None-Match", "*"}, http.StatusNotModified},
+		{"If-None-Match不匹配", []string{"If-None-Match", `"other"`}, http.StatusOK},
+		{"If-Modified-Since未修改", []string{"If-Modified-Since", lastModified}, http.StatusNotModified},
+		{"If-Modified-Since之后有修改", []string{"If-Modified-Since", modified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK},
+		// 同时给出时只按 If-None-Match 判断
+		{"If-None-Match优先", []string{"If-None-Match", `"other"`, "If-Modified-Since", lastModified}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.request(http.MethodGet, path, "", nil, tt.header...)
+			if w.Code != tt.status {
+				t.Fatalf("响应 %d，期望 %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("ETag") != etag) {
+				t.Errorf("304 响应体 %q ETag %q，期望空响应体和 %q", w.Body.String(), w.Header().Get("ETag"), etag)
+			}
+		})
+	}
+
+	t.Run("修改后ETag变化", func(t *testing.T) {
+		patch := fmt.Sprintf(`{"title":"已修改","version":%d}`, article.Version)
+		expect(t, s.request(http.MethodPatch, path, token, patch, "Content-Type", "application/merge-patch+json"), http.StatusOK, "")
+
+		w := s.request(http.MethodGet, path, "", nil, "If-None-Match", etag)
+		expect(t, w, http.StatusOK, "")
+		if w.Header().Get("ETag") == etag {
+			t.Errorf("修改后ETag未变化")
+		}
+	})
+
+	t.Run("文章列表", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/articles", "", nil)
+		expect(t, w, http.StatusOK, "")
+		listETag := w.Header().Get("ETag")
+		// 列表不返回Last-Modified
+		if listETag == "" || w.Header().Get("Last-Modified") != "" {
+			t.Fatalf("列表 ETag %q Last-Modified %q", listETag, w.Header().Get("Last-Modified"))
+		}
+		if w := s.request(http.MethodGet, "/api/articles", "", nil, "If-None-Match", listETag); w.Code != http.StatusNotModified {
+			t.Errorf("列表条件请求响应 %d，期望 304", w.Code)
+		}
+	})
+}
+
+func TestCORSConditionalHeaders(t *testing.T) {
+	s := newServer(t)
+	w := s.request(http.MethodOptions, "/api/articles", "", nil,
+		"Origin", "https://example.com", "Access-Control-Request-Method", "GET")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("预检响应 %d，期望 204", w.Code)
+	}
+
+	allow := w.Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "Idempotency-Key"} {
+		if !strings.Contains(allow, header) {
+			t.Errorf("Access-Control-Allow-Headers 缺少 %s: %s", header, allow)
+		}
+	}
+	expose := w.Header().Get("Access-Control-Expose-Headers")
+	for _, header := range []string{"ETag", "Last-Modified"} {
+		if !strings.Contains(expose, header) {
+			t.Errorf("Access-Control-Expose-Headers 缺少 %s: %s", header, expose)
+		}
+	}
+}
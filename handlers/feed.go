@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// 订阅源格式
+const (
+	feedRSS  = "rss"
+	feedAtom = "atom"
+	feedJSON = "json"
+)
+
+// RSSFeed RSS 2.0 订阅源
+func RSSFeed(c *gin.Context) {
+	writeFeed(c, feedRSS)
+}
+
+// AtomFeed Atom 订阅源
+func AtomFeed(c *gin.Context) {
+	writeFeed(c, feedAtom)
+}
+
+// JSONFeed JSON Feed 订阅源
+func JSONFeed(c *gin.Context) {
+	writeFeed(c, feedJSON)
+}
+
+// writeFeed 输出订阅源，路由中带username参数时为作者订阅源
+func writeFeed(c *gin.Context, format string) {
+	// 调用服务层
+	feed, err := services.BuildFeed(c.Param("username"))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	var body, contentType string
+	switch format {
+	case feedAtom:
+		body, err = feed.ToAtom()
+		contentType = "application/atom+xml; charset=utf-8"
+	case feedJSON:
+		body, err = feed.ToJSON()
+		contentType = "application/feed+json; charset=utf-8"
+	default:
+		body, err = feed.ToRss()
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+	if err != nil {
+		utils.Error(c, utils.ErrInternal.Wrap(err))
+		return
+	}
+
+	if utils.NotModified(c, utils.ETag([]byte(body)), feed.Updated) {
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, []byte(body))
+}
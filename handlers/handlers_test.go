@@ -0,0 +1,172 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/i18n"
+	"github.com/dingdinglz/test-blog/router"
+	"github.com/dingdinglz/test-blog/storage"
+	"github.com/dingdinglz/test-blog/web"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	if err := config.LoadConfig(); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	config.AppConfig.Server.Mode = gin.TestMode
+	if err := i18n.Init(); err != nil {
+		log.Fatalf("加载语言包失败: %v", err)
+	}
+	if err := web.Init(); err != nil {
+		log.Fatalf("加载主题失败: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// testServer 使用独立数据库和上传目录的服务
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+}
+
+// apiResponse 统一的响应格式
+type apiResponse struct {
+	Code    int             `json:"code"`
+	Error   string          `json:"error"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// newServer 创建测试服务
+func newServer(t *testing.T) *testServer {
+	t.Helper()
+	dir := t.TempDir()
+	config.AppConfig.Database.Path = filepath.Join(dir, "blog.db")
+	config.AppConfig.Upload.Local.Dir = filepath.Join(dir, "uploads")
+	if err := database.Init(); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	if err := storage.Init(); err != nil {
+		t.Fatalf("初始化文件存储失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := database.GetDB().DB(); err == nil {
+			db.Close()
+		}
+	})
+	return &testServer{t: t, router: router.SetupRouter()}
+}
+
+// request 发送请求，body为字符串或可以序列化为JSON的值，header中的键值对依次为请求头名和值
+func (s *testServer) request(method, path, token string, body interface{}, header ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			s.t.Fatalf("序列化请求体失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// decode 解析响应，data不为nil时解析响应中的data字段
+func decode(t *testing.T, w *httptest.ResponseRecorder, data interface{}) apiResponse {
+	t.Helper()
+	var resp apiResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v: %s", err, w.Body.String())
+	}
+	if data != nil {
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			t.Fatalf("解析响应数据失败: %v: %s", err, resp.Data)
+		}
+	}
+	return resp
+}
+
+// expect 检查响应的状态码和错误码，errCode为空表示成功
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, errCode string) apiResponse {
+	t.Helper()
+	resp := decode(t, w, nil)
+	if w.Code != status || resp.Error != errCode {
+		t.Fatalf("响应 %d %s，期望 %d %s: %s", w.Code, resp.Error, status, errCode, w.Body.String())
+	}
+	return resp
+}
+
+// register 注册并登录用户，返回token
+func (s *testServer) register(username string) string {
+	s.t.Helper()
+	w := s.request(http.MethodPost, "/api/register", "", map[string]string{
+		"username": username, "email": username + "@example.com", "password": "secret123",
+	})
+	expect(s.t, w, http.StatusOK, "")
+
+	w = s.request(http.MethodPost, "/api/login", "", map[string]string{"username": username, "password": "secret123"})
+	var login struct {
+		Token string `json:"token"`
+	}
+	decode(s.t, w, &login)
+	if login.Token == "" {
+		s.t.Fatalf("登录失败: %s", w.Body.String())
+	}
+	return login.Token
+}
+
+// testArticle 响应中的文章字段
+type testArticle struct {
+	ID      uint   `json:"id"`
+	Title   string `json:"title"`
+	Slug    string `json:"slug"`
+	Status  string `json:"status"`
+	Version uint   `json:"version"`
+}
+
+// createArticle 创建文章
+func (s *testServer) createArticle(token, title string) testArticle {
+	s.t.Helper()
+	w := s.request(http.MethodPost, "/api/articles", token, map[string]interface{}{"title": title, "content": "内容"})
+	var article testArticle
+	if resp := decode(s.t, w, &article); resp.Code != http.StatusOK {
+		s.t.Fatalf("创建文章失败: %s", w.Body.String())
+	}
+	return article
+}
+
+// articlePath 文章接口的路径
+func articlePath(id uint) string {
+	return fmt.Sprintf("/api/articles/%d", id)
+}
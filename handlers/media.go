@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead 请求体中除文件内容外的multipart开销
+const multipartOverhead = 1 << 20
+
+// MaxUploadBody 上传文件请求的请求体大小上限
+func MaxUploadBody() int64 {
+	return services.MaxUploadSize() + multipartOverhead
+}
+
+// mediaResponse 构建文件响应
+func mediaResponse(media *models.Media) models.MediaResponse {
+	variants := make([]models.MediaVariantResponse, 0, len(media.Variants))
+	for _, variant := range media.Variants {
+		variants = append(variants, models.MediaVariantResponse{
+			Name:   variant.Name,
+			Format: variant.Format,
+			URL:    services.MediaURL(variant.Key),
+			Width:  variant.Width,
+			Height: variant.Height,
+			Size:   variant.Size,
+		})
+	}
+
+	return models.MediaResponse{
+		ID:            media.ID,
+		URL:           services.MediaURL(media.Key),
+		Filename:      media.Filename,
+		MimeType:      media.MimeType,
+		Size:          media.Size,
+		UsageCount:    len(media.Articles),
+		Status:        media.Status,
+		Width:         media.Width,
+		Height:        media.Height,
+		Blurhash:      media.Blurhash,
+		DominantColor: media.DominantColor,
+		Variants:      variants,
+		Srcset:        services.SrcSet(media, false),
+		SrcsetWebP:    services.SrcSet(media, true),
+		CreatedAt:     media.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// UploadMedia 上传文件，表单字段为file
+func UploadMedia(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 请求体大小由路由上的 BodyLimit 限制
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.Error(c, services.ErrFileTooLarge)
+			return
+		}
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		utils.Error(c, utils.ErrInternal.Wrap(err))
+		return
+	}
+	defer f.Close()
+
+	// 调用服务层
+	media, err := services.UploadMedia(userID.(uint), file.Filename, f, file.Size)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, mediaResponse(media), utils.MsgCreated)
+}
+
+// GetMyMedia 获取当前用户上传的文件
+func GetMyMedia(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	media, err := services.GetUserMedia(userID.(uint))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 构建响应
+	response := []models.MediaResponse{}
+	for i := range media {
+		response = append(response, mediaResponse(&media[i]))
+	}
+
+	utils.Success(c, response, utils.MsgSuccess)
+}
+
+// DeleteMedia 删除文件
+func DeleteMedia(c *gin.Context) {
+	// 获取文件ID参数
+	mediaID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, utils.ErrInvalidID)
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	if err := services.DeleteMedia(uint(mediaID), userID.(uint)); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, nil, utils.MsgDeleted)
+}
@@ -0,0 +1,49 @@
+package handlers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/dingdinglz/test-blog/config"
+)
+
+// multipartFile 构建只包含file字段的multipart请求体
+func multipartFile(t *testing.T, filename string, content []byte) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+	return buf.String(), writer.FormDataContentType()
+}
+
+func TestUploadMediaTooLarge(t *testing.T) {
+	previous := config.AppConfig.Upload.MaxSizeMB
+	config.AppConfig.Upload.MaxSizeMB = 1
+	t.Cleanup(func() { config.AppConfig.Upload.MaxSizeMB = previous })
+
+	s := newServer(t)
+	token := s.register("alice")
+	// 超过文件大小上限与multipart开销之和，由路由上的请求体限制拦截
+	body, contentType := multipartFile(t, "big.txt", bytes.Repeat([]byte("a"), 3<<20))
+
+	tests := []struct {
+		name   string
+		header []string
+	}{
+		{"无幂等键", []string{"Content-Type", contentType}},
+		{"带幂等键", []string{"Content-Type", contentType, "Idempotency-Key", "upload-big"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.request(http.MethodPost, "/api/media", token, body, tt.header...)
+			expect(t, w, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE")
+		})
+	}
+}
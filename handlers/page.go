@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dingdinglz/test-blog/i18n"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/dingdinglz/test-blog/web"
+	"github.com/gin-gonic/gin"
+)
+
+// writePage 渲染HTML页面，出错时输出错误页面
+func writePage(c *gin.Context, render func(buf *bytes.Buffer, locale string) error) {
+	locale := c.GetString(i18n.ContextKey)
+
+	var buf bytes.Buffer
+	if err := render(&buf, locale); err != nil {
+		buf.Reset()
+		status := web.RenderError(&buf, locale, err)
+		if status >= 500 {
+			log.Printf("[%s] %s %s | %v\n", "ERROR", c.Request.Method, c.Request.URL.Path, err)
+		}
+		c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// HomePage 首页，迁移前 /?p=ID 形式的旧地址重定向到对应文章
+func HomePage(c *gin.Context) {
+	if c.Request.URL.RawQuery != "" && redirectArticle(c) {
+		return
+	}
+
+	page := 1
+	if p := c.Param("page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			writePage(c, func(buf *bytes.Buffer, locale string) error { return utils.ErrNotFound })
+			return
+		}
+		// 第一页使用站点根目录
+		if n == 1 {
+			c.Redirect(http.StatusMovedPermanently, "/")
+			return
+		}
+		page = n
+	}
+
+	writePage(c, func(buf *bytes.Buffer, locale string) error {
+		return web.RenderHome(buf, locale, page)
+	})
+}
+
+// ArticlePage 文章页面，历史slug永久重定向到当前slug
+func ArticlePage(c *gin.Context) {
+	slug := c.Param("slug")
+
+	article, err := services.GetArticleBySlug(slug)
+	if err == nil && !services.ArticleVisible(article, 0) {
+		err = services.ErrArticleNotFound
+	}
+	if err == nil && article.Slug != slug {
+		redirectSlug(c, "/articles/"+article.Slug)
+		return
+	}
+
+	writePage(c, func(buf *bytes.Buffer, locale string) error {
+		if err != nil {
+			return err
+		}
+		return web.RenderArticle(buf, locale, article)
+	})
+}
+
+// AuthorPage 作者页面
+func AuthorPage(c *gin.Context) {
+	writePage(c, func(buf *bytes.Buffer, locale string) error {
+		return web.RenderAuthor(buf, locale, c.Param("username"))
+	})
+}
+
+// TagPage 标签页面
+func TagPage(c *gin.Context) {
+	writePage(c, func(buf *bytes.Buffer, locale string) error {
+		return web.RenderTag(buf, locale, c.Param("slug"))
+	})
+}
+
+// NotFoundPage 未匹配路由时的错误页面，API路由仍返回统一JSON响应，迁移前的旧地址重定向到对应文章
+func NotFoundPage(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		utils.Error(c, utils.ErrNotFound)
+		return
+	}
+	if c.Request.Method == http.MethodGet && redirectArticle(c) {
+		return
+	}
+	writePage(c, func(buf *bytes.Buffer, locale string) error { return utils.ErrNotFound })
+}
+
+// redirectArticle 请求地址是导入文章的旧地址时永久重定向到文章页面
+func redirectArticle(c *gin.Context) bool {
+	slug, err := services.GetArticleRedirect(utils.RedirectPath(c.Request.URL))
+	if err != nil {
+		return false
+	}
+	c.Redirect(http.StatusMovedPermanently, "/articles/"+slug)
+	return true
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Sitemap 站点地图，URL数量超过上限时为站点地图索引
+func Sitemap(c *gin.Context) {
+	writeSitemap(c, 0)
+}
+
+// SitemapPage 站点地图分页，路径形如 /sitemap/1.xml
+func SitemapPage(c *gin.Context) {
+	page, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".xml"))
+	if err != nil || page < 1 {
+		utils.Error(c, utils.ErrNotFound)
+		return
+	}
+	writeSitemap(c, page)
+}
+
+func writeSitemap(c *gin.Context, page int) {
+	// 调用服务层
+	body, lastModified, err := services.BuildSitemap(page)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	if utils.NotModified(c, utils.ETag(body), lastModified) {
+		return
+	}
+
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+// Robots robots.txt
+func Robots(c *gin.Context) {
+	c.String(http.StatusOK, services.BuildRobots())
+}
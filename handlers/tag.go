@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllTags 获取所有标签
+func GetAllTags(c *gin.Context) {
+	// 调用服务层
+	tags, err := services.GetAllTags()
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, tagResponses(tags), utils.MsgSuccess)
+}
+
+// GetTagArticles 获取指定标签下的文章
+func GetTagArticles(c *gin.Context) {
+	// 解析返回的字段
+	view, err := parseArticleView(c, false)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 调用服务层
+	tag, err := services.GetTagBySlug(c.Param("slug"))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	articles, err := services.GetTagArticles(tag.ID, view.load())
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	respondArticles(c, view, articles)
+}
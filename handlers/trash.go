@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash 获取当前用户回收站中的文章
+func GetTrash(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	articles, err := services.GetTrashArticles(userID.(uint))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	response := make([]models.TrashArticleResponse, 0, len(articles))
+	for i := range articles {
+		article := &articles[i]
+		response = append(response, models.TrashArticleResponse{
+			ID:        article.ID,
+			Title:     article.Title,
+			Slug:      article.Slug,
+			Excerpt:   services.ArticleExcerpt(article),
+			Status:    article.Status,
+			CreatedAt: article.CreatedAt.Format("2006-01-02 15:04:05"),
+			UpdatedAt: article.UpdatedAt.Format("2006-01-02 15:04:05"),
+			DeletedAt: article.DeletedAt.Time.Format("2006-01-02 15:04:05"),
+			PurgeAt:   services.TrashPurgeTime(article).Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	utils.Success(c, response, utils.MsgSuccess)
+}
+
+// RestoreArticle 从回收站恢复文章
+func RestoreArticle(c *gin.Context) {
+	// 获取文章ID参数
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, utils.ErrInvalidID)
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 解析返回的字段
+	view, err := parseArticleView(c, true)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	// 调用服务层
+	article, err := services.RestoreArticle(uint(articleID), userID.(uint))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	respondArticle(c, view, article, utils.MsgRestored)
+}
+
+// PurgeArticle 永久删除回收站中的文章
+func PurgeArticle(c *gin.Context) {
+	// 获取文章ID参数
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Error(c, utils.ErrInvalidID)
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	if err := services.PurgeArticle(uint(articleID), userID.(uint)); err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, nil, utils.MsgDeleted)
+}
+
+// EmptyTrash 清空当前用户的回收站
+func EmptyTrash(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	count, err := services.EmptyTrash(userID.(uint))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, gin.H{"deleted": count}, utils.MsgDeleted)
+}
@@ -1,6 +1,12 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
 	"github.com/dingdinglz/test-blog/models"
 	"github.com/dingdinglz/test-blog/services"
 	"github.com/dingdinglz/test-blog/utils"
@@ -14,24 +20,97 @@ type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 }
 
+// PrivacyRequest 隐私设置请求
+type PrivacyRequest struct {
+	ShowEmail *bool `json:"show_email" binding:"required"`
+}
+
+// ProfileRequest 更新个人资料请求，未传的字段保持不变
+type ProfileRequest struct {
+	DisplayName   *string             `json:"display_name" binding:"omitempty,max=64"`
+	Bio           *string             `json:"bio" binding:"omitempty,max=500"`
+	Website       *string             `json:"website" binding:"omitempty,max=255"`
+	Avatar        *string             `json:"avatar" binding:"omitempty,max=512"`
+	AvatarMediaID *uint               `json:"avatar_media_id"`
+	SocialLinks   []SocialLinkRequest `json:"social_links" binding:"omitempty,max=10,dive"`
+}
+
+// SocialLinkRequest 社交账号链接
+type SocialLinkRequest struct {
+	Name string `json:"name" binding:"required,max=32"`
+	URL  string `json:"url" binding:"required,max=255"`
+}
+
+// DeletionRequest 申请注销账户请求，需要再次输入密码确认
+type DeletionRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
 
+// userResponse 构建用户本人的账户信息
+func userResponse(user *models.User) models.UserResponse {
+	response := models.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Avatar:        user.Avatar,
+		AvatarMediaID: user.AvatarMediaID,
+		AvatarURL:     services.AvatarURL(user),
+		Website:       user.Website,
+		SocialLinks:   socialLinks(user.SocialLinks),
+		Role:          user.Role,
+		ShowEmail:     user.ShowEmail,
+		CreatedAt:     user.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if user.DeleteAt != nil {
+		deleteAt := user.DeleteAt.Format("2006-01-02 15:04:05")
+		response.DeleteAt = &deleteAt
+	}
+	return response
+}
+
+// socialLinks 未设置社交链接时返回空数组
+func socialLinks(links []models.SocialLink) []models.SocialLink {
+	if links == nil {
+		return []models.SocialLink{}
+	}
+	return links
+}
+
+// authorResponse 构建公开的作者资料，邮箱按隐私设置和请求用户决定是否返回
+func authorResponse(user *models.User, viewer services.Viewer) models.AuthorResponse {
+	response := models.AuthorResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.Name(),
+		Avatar:      services.AvatarURL(user),
+		Bio:         user.Bio,
+	}
+	if services.CanViewEmail(user, viewer) {
+		response.Email = user.Email
+	}
+	return response
+}
+
 // Register 用户注册
 func Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Error(c, 400, "参数错误: "+err.Error())
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
 		return
 	}
 
 	// 调用服务层
 	user, err := services.Register(req.Username, req.Password, req.Email)
 	if err != nil {
-		utils.Error(c, 400, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
@@ -40,34 +119,29 @@ func Register(c *gin.Context) {
 		"user_id":  user.ID,
 		"username": user.Username,
 		"email":    user.Email,
-	}, "注册成功")
+	}, utils.MsgRegistered)
 }
 
 // Login 用户登录
 func Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.Error(c, 400, "参数错误: "+err.Error())
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
 		return
 	}
 
 	// 调用服务层
 	token, user, err := services.Login(req.Username, req.Password)
 	if err != nil {
-		utils.Error(c, 400, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
 	// 返回响应
 	utils.Success(c, gin.H{
 		"token": token,
-		"user": models.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt.Format("2006-01-02 15:04:05"),
-		},
-	}, "登录成功")
+		"user":  userResponse(user),
+	}, utils.MsgLoggedIn)
 }
 
 // GetInfo 获取当前用户信息
@@ -75,22 +149,179 @@ func GetInfo(c *gin.Context) {
 	// 从Context获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.Error(c, 401, "未授权")
+		utils.Error(c, utils.ErrUnauthorized)
 		return
 	}
 
 	// 调用服务层
 	user, err := services.GetUserByID(userID.(uint))
 	if err != nil {
-		utils.Error(c, 404, err.Error())
+		utils.Error(c, err)
 		return
 	}
 
 	// 返回响应
-	utils.Success(c, models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format("2006-01-02 15:04:05"),
-	}, "success")
+	utils.Success(c, userResponse(user), utils.MsgSuccess)
+}
+
+// UpdatePrivacy 更新当前用户的隐私设置
+func UpdatePrivacy(c *gin.Context) {
+	var req PrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	user, err := services.UpdatePrivacy(userID.(uint), *req.ShowEmail)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, userResponse(user), utils.MsgUpdated)
+}
+
+// UpdateProfile 更新当前用户的个人资料
+func UpdateProfile(c *gin.Context) {
+	var req ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	input := services.ProfileInput{
+		DisplayName:   req.DisplayName,
+		Bio:           req.Bio,
+		Website:       req.Website,
+		Avatar:        req.Avatar,
+		AvatarMediaID: req.AvatarMediaID,
+	}
+	if req.SocialLinks != nil {
+		input.SocialLinks = make([]models.SocialLink, 0, len(req.SocialLinks))
+		for _, link := range req.SocialLinks {
+			input.SocialLinks = append(input.SocialLinks, models.SocialLink{Name: link.Name, URL: link.URL})
+		}
+	}
+
+	// 调用服务层
+	user, err := services.UpdateProfile(userID.(uint), input)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, userResponse(user), utils.MsgUpdated)
+}
+
+// GetUserProfile 获取用户的公开主页资料
+func GetUserProfile(c *gin.Context) {
+	// 调用服务层
+	profile, err := services.GetUserProfile(c.Param("username"))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	viewer := services.GetViewer(c.GetUint("user_id"))
+	response := models.ProfileResponse{
+		AuthorResponse: authorResponse(profile.User, viewer),
+		Website:        profile.User.Website,
+		SocialLinks:    socialLinks(profile.User.SocialLinks),
+		ArticleCount:   profile.ArticleCount,
+		CreatedAt:      profile.User.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	// 草稿数只对本人和管理员可见
+	if viewer.Admin || viewer.ID == profile.User.ID {
+		response.DraftCount = &profile.DraftCount
+	}
+
+	utils.Success(c, response, utils.MsgSuccess)
+}
+
+// ExportUserData 导出当前用户的全部数据，返回ZIP文件
+func ExportUserData(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	export, err := services.PrepareUserExport(userID.(uint))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-export-%s.zip", export.User.Username, time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// 响应已开始发送，出错时只能中断连接
+	if err := export.WriteZip(c.Writer); err != nil {
+		log.Printf("导出用户 %d 的数据失败: %v\n", export.User.ID, err)
+		c.Abort()
+	}
+}
+
+// RequestAccountDeletion 申请注销当前账户
+func RequestAccountDeletion(c *gin.Context) {
+	var req DeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	user, err := services.RequestAccountDeletion(userID.(uint), req.Password)
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, userResponse(user), utils.MsgUpdated)
+}
+
+// CancelAccountDeletion 撤销注销申请
+func CancelAccountDeletion(c *gin.Context) {
+	// 从Context获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.Error(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// 调用服务层
+	user, err := services.CancelAccountDeletion(userID.(uint))
+	if err != nil {
+		utils.Error(c, err)
+		return
+	}
+
+	utils.Success(c, userResponse(user), utils.MsgUpdated)
 }
@@ -0,0 +1,143 @@
+package i18n
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dingdinglz/test-blog/config"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/text/language"
+)
+
+// ContextKey 协商出的语言在gin.Context中的键
+const ContextKey = "locale"
+
+//go:embed locales/*.yaml
+var embedded embed.FS
+
+var (
+	// catalogs 语言 -> 消息键 -> 文本
+	catalogs      = map[string]map[string]string{}
+	locales       []string
+	matcher       language.Matcher
+	defaultLocale = "zh-CN"
+)
+
+// Init 加载内置语言包和配置目录中的语言包，目录中的同名语言会覆盖内置文本
+func Init() error {
+	cfg := config.AppConfig.I18n
+	if cfg.DefaultLocale != "" {
+		defaultLocale = cfg.DefaultLocale
+	}
+
+	if err := loadFS(embedded, "locales"); err != nil {
+		return err
+	}
+
+	if cfg.LocalesDir != "" {
+		if _, err := os.Stat(cfg.LocalesDir); err == nil {
+			if err := loadFS(os.DirFS(cfg.LocalesDir), "."); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	if _, ok := catalogs[defaultLocale]; !ok {
+		return fmt.Errorf("默认语言 %s 没有对应的语言包", defaultLocale)
+	}
+
+	// 默认语言放在首位，作为匹配失败时的回退
+	locales = []string{defaultLocale}
+	tags := []language.Tag{language.Make(defaultLocale)}
+	for locale := range catalogs {
+		if locale != defaultLocale {
+			locales = append(locales, locale)
+			tags = append(tags, language.Make(locale))
+		}
+	}
+	matcher = language.NewMatcher(tags)
+
+	if err := registerValidatorTranslations(); err != nil {
+		return err
+	}
+
+	log.Printf("语言包加载成功: %s\n", strings.Join(locales, ", "))
+	return nil
+}
+
+// loadFS 读取目录下的 <语言>.yaml 文件
+func loadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		var messages map[string]string
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("解析语言包 %s 失败: %w", entry.Name(), err)
+		}
+
+		locale := strings.TrimSuffix(entry.Name(), ext)
+		if catalogs[locale] == nil {
+			catalogs[locale] = map[string]string{}
+		}
+		for key, text := range messages {
+			catalogs[locale][key] = text
+		}
+	}
+
+	return nil
+}
+
+// DefaultLocale 获取默认语言
+func DefaultLocale() string {
+	return defaultLocale
+}
+
+// Match 根据Accept-Language请求头选择最合适的语言
+func Match(acceptLanguage string) string {
+	if matcher == nil {
+		return defaultLocale
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return defaultLocale
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return defaultLocale
+	}
+	return locales[index]
+}
+
+// Message 获取指定语言的文本，缺失时依次回退到默认语言和fallback
+func Message(locale, key, fallback string) string {
+	if text, ok := catalogs[locale][key]; ok {
+		return text
+	}
+	if text, ok := catalogs[defaultLocale][key]; ok {
+		return text
+	}
+	return fallback
+}
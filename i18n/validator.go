@@ -0,0 +1,72 @@
+package i18n
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	"golang.org/x/text/language"
+)
+
+// translators 按基础语言（en、zh）索引的参数校验翻译器
+var translators = map[string]ut.Translator{}
+
+// registerValidatorTranslations 为gin使用的校验器注册中英文错误翻译
+func registerValidatorTranslations() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+
+	// 错误信息中使用json字段名而不是Go结构体字段名
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	uni := ut.New(en.New(), en.New(), zh.New())
+
+	enTrans, _ := uni.GetTranslator("en")
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+	zhTrans, _ := uni.GetTranslator("zh")
+	if err := zh_translations.RegisterDefaultTranslations(v, zhTrans); err != nil {
+		return err
+	}
+
+	translators["en"] = enTrans
+	translators["zh"] = zhTrans
+	return nil
+}
+
+// TranslateValidation 翻译参数校验错误，没有对应翻译器的语言使用英文
+func TranslateValidation(locale string, errs validator.ValidationErrors) []string {
+	base, _ := language.Make(locale).Base()
+	trans, ok := translators[base.String()]
+	if !ok {
+		trans, ok = translators["en"]
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, fe := range errs {
+		if ok {
+			messages = append(messages, fe.Translate(trans))
+		} else {
+			messages = append(messages, fe.Error())
+		}
+	}
+	return messages
+}
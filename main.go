@@ -2,10 +2,17 @@ package main
 
 import (
 	"log"
+	"os"
 
+	"github.com/dingdinglz/test-blog/cache"
+	"github.com/dingdinglz/test-blog/cli"
 	"github.com/dingdinglz/test-blog/config"
 	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/i18n"
 	"github.com/dingdinglz/test-blog/router"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/storage"
+	"github.com/dingdinglz/test-blog/web"
 )
 
 func main() {
@@ -14,11 +21,53 @@ func main() {
 		log.Fatalf("配置加载失败: %v", err)
 	}
 
+	// 加载语言包
+	if err := i18n.Init(); err != nil {
+		log.Fatalf("语言包加载失败: %v", err)
+	}
+
+	// 加载页面主题
+	if err := web.Init(); err != nil {
+		log.Fatalf("主题加载失败: %v", err)
+	}
+
 	// 初始化数据库
 	if err := database.Init(); err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
 
+	// 初始化文件存储
+	if err := storage.Init(); err != nil {
+		log.Fatalf("文件存储初始化失败: %v", err)
+	}
+
+	// 为旧文章补充slug
+	if err := services.BackfillSlugs(); err != nil {
+		log.Fatalf("生成文章slug失败: %v", err)
+	}
+
+	// 执行命令行子命令
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("命令执行失败: %v", err)
+		}
+		return
+	}
+
+	// 初始化读缓存，命令行子命令不使用缓存
+	if err := cache.Init(); err != nil {
+		log.Fatalf("读缓存初始化失败: %v", err)
+	}
+
+	// 启动后台图片处理
+	services.StartImageWorkers()
+
+	// 定期清理回收站
+	services.StartTrashPurge()
+
+	// 定期注销到期的账户
+	services.StartAccountPurge()
+
 	// 设置路由
 	r := router.SetupRouter()
 
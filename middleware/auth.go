@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
+	"github.com/dingdinglz/test-blog/services"
 	"github.com/dingdinglz/test-blog/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -13,7 +15,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 从请求头获取token
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			utils.Error(c, 401, "未提供认证token")
+			utils.Error(c, utils.ErrTokenMissing)
 			c.Abort()
 			return
 		}
@@ -21,7 +23,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 验证token格式: Bearer token
 		parts := strings.SplitN(authHeader, " ", 2)
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			utils.Error(c, 401, "token格式错误")
+			utils.Error(c, utils.ErrTokenMalformed)
 			c.Abort()
 			return
 		}
@@ -29,7 +31,17 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 解析token
 		claims, err := utils.ParseToken(parts[1])
 		if err != nil {
-			utils.Error(c, 401, "token无效或已过期")
+			utils.Error(c, utils.ErrTokenInvalid.Wrap(err))
+			c.Abort()
+			return
+		}
+
+		// 账户注销后token随之失效
+		if _, err := services.GetUserByID(claims.UserID); err != nil {
+			if errors.Is(err, services.ErrUserNotFound) {
+				err = utils.ErrTokenInvalid.Wrap(err)
+			}
+			utils.Error(c, err)
 			c.Abort()
 			return
 		}
@@ -41,3 +53,23 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware 可选认证中间件，提供有效token且用户仍存在时写入用户信息，否则按未登录处理
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			claims, err := utils.ParseToken(parts[1])
+			if err == nil {
+				// 与 AuthMiddleware 相同，已注销账户的token不再有效
+				_, err = services.GetUserByID(claims.UserID)
+			}
+			if err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("username", claims.Username)
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl 按 http_cache.rules 为GET请求设置 Cache-Control 响应头。
+// 带Authorization的请求可能包含草稿和作者邮箱，只允许客户端缓存并每次重新验证；
+// 响应消息随Accept-Language变化，需要CDN按这两个请求头区分缓存
+func CacheControl() gin.HandlerFunc {
+	rules := map[string]string{}
+	for _, rule := range config.AppConfig.HTTPCache.Rules {
+		rules[rule.Route] = rule.CacheControl
+	}
+
+	return func(c *gin.Context) {
+		cacheControl, ok := rules[c.FullPath()]
+		if !ok || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		if c.GetHeader("Authorization") != "" {
+			cacheControl = "private, no-cache"
+		}
+		c.Header("Cache-Control", cacheControl)
+		c.Header("Vary", "Accept-Language, Authorization")
+
+		c.Next()
+	}
+}
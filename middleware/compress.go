@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/dingdinglz/test-blog/cache"
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// encoder 压缩编码器，写入完成后需调用Close
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoderPools 各编码的编码器池，键为 Content-Encoding 的值
+var encoderPools = map[string]*sync.Pool{
+	"br": {New: func() interface{} {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	}},
+	"zstd": {New: func() interface{} {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+	"gzip": {New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}},
+}
+
+// 响应体的压缩状态
+const (
+	compressPending   = iota // 尚未写入响应体
+	compressBuffering        // 响应体未达到最小压缩大小，暂存在buf中
+	compressPlain            // 不压缩
+	compressActive           // 压缩
+)
+
+// compressWriter 按需压缩响应体，响应体达到最小压缩大小后才开始压缩
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding  string
+	minSize   int
+	types     map[string]bool
+	store     cache.Cache // 压缩结果缓存，为nil时不缓存
+	cacheable bool        // 由 CompressCache 设置，响应带ETag时缓存压缩结果
+
+	state    int
+	buf      bytes.Buffer
+	encoder  encoder
+	cacheKey string // 需要缓存压缩结果时的键，压缩结果写入buf
+	cached   []byte // 命中缓存的压缩结果，处理器写入的响应体被忽略
+}
+
+// Write 实现 io.Writer
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.state == compressPending {
+		w.state = compressPlain
+		if w.compressible() {
+			w.state = compressBuffering
+		}
+	}
+
+	switch w.state {
+	case compressBuffering:
+		w.buf.Write(data)
+		if w.buf.Len() >= w.minSize {
+			if err := w.start(); err != nil {
+				return 0, err
+			}
+		}
+		return len(data), nil
+	case compressActive:
+		if w.cached != nil {
+			return len(data), nil
+		}
+		return w.encoder.Write(data)
+	default:
+		return w.ResponseWriter.Write(data)
+	}
+}
+
+// WriteString 实现 io.StringWriter
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 实现 http.Flusher，暂存的响应体不再等待达到最小压缩大小
+func (w *compressWriter) Flush() {
+	if w.state == compressBuffering {
+		if err := w.start(); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil && w.cacheKey == "" {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// compressible 响应是否需要压缩：成功响应、未经编码且Content-Type在 compression.types 中
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusPartialContent || status == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return w.types[mediaType]
+}
+
+// start 开始压缩，写出暂存的响应体
+func (w *compressWriter) start() error {
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+
+	// 压缩后的内容与原响应不同，强ETag改为弱ETag，If-None-Match 使用弱比较，不影响条件请求
+	etag := header.Get("ETag")
+	if etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+
+	pending := w.buf.Bytes()
+	w.state = compressActive
+	if w.cacheable && w.store != nil && etag != "" && w.Status() == http.StatusOK {
+		w.cacheKey = w.encoding + " " + etag
+		if value, ok := w.store.Get(w.cacheKey); ok {
+			w.cached = value.([]byte)
+			return nil
+		}
+		// 压缩结果写入buf，完成后缓存并一次写出
+		pending = bytes.Clone(pending)
+		w.buf.Reset()
+		w.encoder = encoderPools[w.encoding].Get().(encoder)
+		w.encoder.Reset(&w.buf)
+	} else {
+		w.encoder = encoderPools[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	_, err := w.encoder.Write(pending)
+	return err
+}
+
+// close 写出剩余的响应体，处理器返回后调用
+func (w *compressWriter) close() {
+	switch w.state {
+	case compressBuffering:
+		// 响应体未达到最小压缩大小，不压缩
+		w.ResponseWriter.Write(w.buf.Bytes())
+	case compressActive:
+		if w.cached == nil {
+			err := w.encoder.Close()
+			w.encoder.Reset(nil)
+			encoderPools[w.encoding].Put(w.encoder)
+			if err != nil {
+				log.Printf("压缩响应失败: %v\n", err)
+				return
+			}
+			if w.cacheKey == "" {
+				return
+			}
+			w.cached = bytes.Clone(w.buf.Bytes())
+			w.store.Set(w.cacheKey, w.cached)
+		}
+		w.ResponseWriter.Write(w.cached)
+	}
+}
+
+// negotiateEncoding 按 Accept-Encoding 从supported中选择编码，supported按服务端的优先顺序排列，
+// q值较大的优先；客户端不接受任何支持的编码时返回空字符串
+func negotiateEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		weight := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				weight = q
+			}
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, encoding := range supported {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+// Compress 响应压缩中间件，按 Accept-Encoding 选择 compression.encodings 中的编码，
+// 只压缩 compression.types 中的内容类型且不小于 compression.min_size 字节的响应。
+// 需在设置Vary响应头的中间件之后使用
+func Compress() gin.HandlerFunc {
+	cfg := config.AppConfig.Compression
+
+	types := map[string]bool{}
+	for _, t := range cfg.Types {
+		types[strings.ToLower(t)] = true
+	}
+	var encodings []string
+	for _, encoding := range cfg.Encodings {
+		if _, ok := encoderPools[encoding]; !ok {
+			log.Printf("不支持的压缩编码: %s\n", encoding)
+			continue
+		}
+		encodings = append(encodings, encoding)
+	}
+	var store cache.Cache
+	if cfg.CacheSize > 0 {
+		store = cache.NewMemory(cfg.CacheSize, 0)
+	}
+
+	return func(c *gin.Context) {
+		if !cfg.Enabled || len(encodings) == 0 {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), encodings)
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        cfg.MinSize,
+			types:          types,
+			store:          store,
+		}
+		c.Writer = w
+		c.Next()
+		w.close()
+	}
+}
+
+// CompressCache 缓存路由响应的压缩结果，用于订阅源、站点地图等内容很少变化的响应；
+// 按编码和ETag缓存，内容变化后ETag随之变化，不需要清除
+func CompressCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if w, ok := c.Writer.(*compressWriter); ok {
+			w.cacheable = true
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	supported := []string{"br", "zstd", "gzip"}
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"未提供", "", ""},
+		{"单个编码", "gzip", "gzip"},
+		{"按服务端顺序", "gzip, zstd, br", "br"},
+		{"q值较大的优先", "br;q=0.5, gzip", "gzip"},
+		{"q值相同时按服务端顺序", "gzip;q=0.8, zstd;q=0.8", "zstd"},
+		{"q值带空格", "br; q=0.2, gzip; q=0.9", "gzip"},
+		{"q=0表示不接受", "br;q=0, zstd;q=0, gzip", "gzip"},
+		{"大小写不敏感", "GZIP", "gzip"},
+		{"通配符", "*", "br"},
+		{"通配符不覆盖显式的q值", "*, br;q=0", "zstd"},
+		{"未列出的编码使用通配符的q值", "gzip;q=0.5, *;q=0.8", "br"},
+		{"通配符q=0", "*;q=0", ""},
+		{"只接受identity", "identity", ""},
+		{"identity;q=0", "identity;q=0", ""},
+		{"identity;q=0且接受gzip", "identity;q=0, gzip", "gzip"},
+		{"不支持的编码", "deflate, compress", ""},
+		{"无效的q值视为1", "gzip;q=abc", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateEncoding(tt.header, supported); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q，期望 %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+// decode 按 Content-Encoding 解压响应体
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "":
+		return string(body)
+	case "gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("解压gzip失败: %v", err)
+		}
+		r = gr
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("解压zstd失败: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	case "br":
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		t.Fatalf("未知的编码: %s", encoding)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("解压%s失败: %v", encoding, err)
+	}
+	return string(data)
+}
+
+func TestCompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{Compression: config.CompressionConfig{
+		Enabled:   true,
+		Encodings: []string{"br", "zstd", "gzip"},
+		MinSize:   64,
+		Types:     []string{"application/json", "text/plain"},
+		CacheSize: 10,
+	}}
+
+	large := strings.Repeat("compressible ", 100)
+	tests := []struct {
+		name         string
+		method       string
+		accept       string
+		status       int
+		contentType  string
+		etag         string
+		cacheable    bool
+		preEncoded   bool
+		body         string
+		wantEncoding string
+		wantETag     string // 为空时期望与etag相同
+	}{
+		{name: "gzip", accept: "gzip", contentType: "text/plain", body: large, wantEncoding: "gzip"},
+		{name: "zstd", accept: "zstd, gzip;q=0.5", contentType: "text/plain", body: large, wantEncoding: "zstd"},
+		{name: "br", accept: "gzip, br", contentType: "text/plain; charset=utf-8", body: large, wantEncoding: "br"},
+		{name: "小于最小压缩大小", accept: "gzip", contentType: "text/plain", body: "small"},
+		{name: "不压缩的类型", accept: "gzip", contentType: "image/png", body: large},
+		{name: "identity", accept: "identity", contentType: "text/plain", body: large},
+		{name: "identity;q=0", accept: "identity;q=0", contentType: "text/plain", body: large},
+		{name: "HEAD请求", method: http.MethodHead, accept: "gzip", contentType: "text/plain"},
+		{name: "已编码的响应", accept: "gzip", contentType: "text/plain", preEncoded: true, body: large},
+		{name: "未修改", accept: "gzip", status: http.StatusNotModified, contentType: "text/plain", etag: `"v1"`},
+		{name: "强ETag改为弱ETag", accept: "gzip", contentType: "application/json", etag: `"v1"`, body: large, wantEncoding: "gzip", wantETag: `W/"v1"`},
+		{name: "弱ETag不变", accept: "gzip", contentType: "application/json", etag: `W/"v1"`, body: large, wantEncoding: "gzip"},
+		{name: "不压缩时ETag不变", accept: "gzip", contentType: "application/json", etag: `"v1"`, body: "small"},
+		{name: "缓存时类型不可压缩", accept: "br", contentType: "application/xml", etag: `"feed"`, cacheable: true, body: large},
+		{name: "缓存压缩结果且类型可压缩", accept: "br", contentType: "text/plain", etag: `"feed"`, cacheable: true, body: large, wantEncoding: "br", wantETag: `W/"feed"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Compress())
+			handlers := []gin.HandlerFunc{}
+			if tt.cacheable {
+				handlers = append(handlers, CompressCache())
+			}
+			handlers = append(handlers, func(c *gin.Context) {
+				if tt.etag != "" {
+					c.Header("ETag", tt.etag)
+				}
+				if tt.preEncoded {
+					c.Header("Content-Encoding", "custom")
+				}
+				status := tt.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				if status == http.StatusNotModified {
+					c.Status(status)
+					return
+				}
+				c.Data(status, tt.contentType, []byte(tt.body))
+			})
+			r.Handle(http.MethodGet, "/", handlers...)
+			r.Handle(http.MethodHead, "/", handlers...)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			// 缓存压缩结果时请求两次，第二次使用缓存
+			requests := 1
+			if tt.cacheable {
+				requests = 2
+			}
+			for i := 0; i < requests; i++ {
+				req := httptest.NewRequest(method, "/", nil)
+				req.Header.Set("Accept-Encoding", tt.accept)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+					t.Errorf("缺少 Vary: Accept-Encoding")
+				}
+				encoding := w.Header().Get("Content-Encoding")
+				if tt.preEncoded {
+					if encoding != "custom" {
+						t.Errorf("Content-Encoding = %q，期望保留处理器设置的值", encoding)
+					}
+					continue
+				}
+				if encoding != tt.wantEncoding {
+					t.Fatalf("Content-Encoding = %q，期望 %q", encoding, tt.wantEncoding)
+				}
+				wantETag := tt.wantETag
+				if wantETag == "" {
+					wantETag = tt.etag
+				}
+				if etag := w.Header().Get("ETag"); etag != wantETag {
+					t.Errorf("ETag = %q，期望 %q", etag, wantETag)
+				}
+				if method == http.MethodHead {
+					continue
+				}
+				if got := decode(t, encoding, w.Body.Bytes()); got != tt.body {
+					t.Errorf("响应体与原始内容不一致，长度 %d，期望 %d", len(got), len(tt.body))
+				}
+			}
+		})
+	}
+}
+
+func TestCompressDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{Compression: config.CompressionConfig{
+		Enabled:   false,
+		Encodings: []string{"gzip"},
+		Types:     []string{"text/plain"},
+	}}
+
+	r := gin.New()
+	r.Use(Compress())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("a", 4096))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if encoding := w.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("关闭压缩时 Content-Encoding = %q", encoding)
+	}
+	if vary := w.Header().Get("Vary"); vary != "" {
+		t.Errorf("关闭压缩时 Vary = %q", vary)
+	}
+}
@@ -0,0 +1,245 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotencyKeyLength Idempotency-Key 的最大长度
+const maxIdempotencyKeyLength = 255
+
+// maxMemoryBody 计算指纹时保存在内存中的请求体大小上限，超过时写入临时文件
+const maxMemoryBody = 1 << 20
+
+// replayHeaders 重放响应时恢复的响应头
+var replayHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// BodyLimit 限制请求体大小，需在读取请求体的中间件（如 Idempotency）之前使用
+func BodyLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// spooledBody 请求体的副本，不超过 maxMemoryBody 时保存在内存中，否则写入临时文件
+type spooledBody struct {
+	buf  bytes.Buffer
+	file *os.File
+}
+
+// Write 实现 io.Writer
+func (s *spooledBody) Write(data []byte) (int, error) {
+	if s.file == nil && s.buf.Len()+len(data) <= maxMemoryBody {
+		return s.buf.Write(data)
+	}
+	if s.file == nil {
+		file, err := os.CreateTemp("", "idempotency-*")
+		if err != nil {
+			return 0, err
+		}
+		s.file = file
+		if _, err := file.Write(s.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		s.buf = bytes.Buffer{}
+	}
+	return s.file.Write(data)
+}
+
+// reader 从头读取请求体
+func (s *spooledBody) reader() (io.Reader, error) {
+	if s.file == nil {
+		return bytes.NewReader(s.buf.Bytes()), nil
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+// close 删除临时文件
+func (s *spooledBody) close() {
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+}
+
+// responseRecorder 在写出响应的同时保留一份响应体
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 实现 io.Writer
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 实现 io.StringWriter
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency 幂等键中间件，用于创建资源的接口，需在认证中间件之后使用。
+// 带 Idempotency-Key 请求头的请求会保存响应 idempotency.ttl 小时，同一用户使用相同的键重试时
+// 直接返回保存的响应并附带 Idempotent-Replayed: true；5xx响应不保存，可以使用相同的键重试
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.Error(c, utils.ErrInvalidParams.Wrap(fmt.Errorf("Idempotency-Key 超过%d个字符", maxIdempotencyKeyLength)))
+			c.Abort()
+			return
+		}
+
+		// 读取请求体计算指纹，之后放回供处理器读取
+		body := &spooledBody{}
+		defer body.close()
+		fingerprint, err := requestFingerprint(c.Request, body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				utils.Error(c, services.ErrFileTooLarge)
+			} else {
+				utils.Error(c, utils.ErrInvalidParams.Wrap(err))
+			}
+			c.Abort()
+			return
+		}
+		reader, err := body.reader()
+		if err != nil {
+			utils.Error(c, utils.ErrInternal.Wrap(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(reader)
+
+		record, err := services.BeginIdempotentRequest(c.GetUint("user_id"), key, fingerprint)
+		if err != nil {
+			utils.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		// 重放保存的响应
+		if record.Status != 0 {
+			for name, value := range record.Header {
+				c.Header(name, value)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.Status, record.Header["Content-Type"], record.Body)
+			c.Abort()
+			return
+		}
+
+		// 处理器panic时删除处理中的记录，否则在锁定超时前重试都会返回 ErrIdempotencyBusy；之后继续交给Recovery处理
+		defer func() {
+			if r := recover(); r != nil {
+				if err := services.AbortIdempotentRequest(record); err != nil {
+					log.Printf("[%s] %s %s | %v\n", "ERROR", c.Request.Method, c.Request.URL.Path, err)
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			err = services.AbortIdempotentRequest(record)
+		} else {
+			header := map[string]string{}
+			for _, name := range replayHeaders {
+				if value := c.Writer.Header().Get(name); value != "" {
+					header[name] = value
+				}
+			}
+			err = services.CompleteIdempotentRequest(record, status, header, recorder.body.Bytes())
+		}
+		if err != nil {
+			log.Printf("[%s] %s %s | %v\n", "ERROR", c.Request.Method, c.Request.URL.Path, err)
+		}
+	}
+}
+
+// requestFingerprint 把请求体复制到body，同时计算请求方法、地址和请求体的SHA256；
+// multipart请求体的分隔符每次随机生成，改为按顺序计算各部分的字段名、文件名和内容
+func requestFingerprint(r *http.Request, body *spooledBody) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if _, err := io.Copy(body, io.TeeReader(r.Body, h)); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	if _, err := io.Copy(body, r.Body); err != nil {
+		return "", err
+	}
+	content, err := body.reader()
+	if err != nil {
+		return "", err
+	}
+	if !writeMultipartDigest(h, content, params["boundary"]) {
+		if content, err = body.reader(); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(h, content); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeMultipartDigest 把multipart请求体的各部分写入h，解析失败时返回false
+func writeMultipartDigest(h hash.Hash, body io.Reader, boundary string) bool {
+	if boundary == "" {
+		return false
+	}
+
+	var digest bytes.Buffer
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false
+		}
+		sum := sha256.New()
+		if _, err := io.Copy(sum, part); err != nil {
+			return false
+		}
+		fmt.Fprintf(&digest, "%q %q %x\n", part.FormName(), part.FileName(), sum.Sum(nil))
+	}
+	h.Write(digest.Bytes())
+	return true
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyRouter 使用独立数据库的路由，handler 处理 POST /items
+func idempotencyRouter(t *testing.T, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{
+		Database:    config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "blog.db")},
+		Idempotency: config.IdempotencyConfig{TTL: 24},
+	}
+	if err := database.Init(); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := database.GetDB().DB(); err == nil {
+			db.Close()
+		}
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.POST("/items", func(c *gin.Context) { c.Set("user_id", uint(1)) }, Idempotency(), handler)
+	return r
+}
+
+// postItem 使用幂等键提交请求
+func postItem(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// errorCode 响应中的错误码
+func errorCode(w *httptest.ResponseRecorder) string {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Error
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	calls := 0
+	r := idempotencyRouter(t, func(c *gin.Context) {
+		calls++
+		c.Header("Location", "/items/1")
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	first := postItem(r, "key-1", `{"name":"a"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("首次请求响应 %d", first.Code)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		body     string
+		status   int
+		errCode  string
+		replayed bool
+		calls    int
+	}{
+		{"相同请求重放", "key-1", `{"name":"a"}`, http.StatusCreated, "", true, 1},
+		{"相同的键用于不同请求", "key-1", `{"name":"b"}`, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", false, 1},
+		{"不同的键", "key-2", `{"name":"a"}`, http.StatusCreated, "", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postItem(r, tt.key, tt.body)
+			if w.Code != tt.status || errorCode(w) != tt.errCode {
+				t.Fatalf("响应 %d %s，期望 %d %s", w.Code, errorCode(w), tt.status, tt.errCode)
+			}
+			if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+				t.Errorf("Idempotent-Replayed %t，期望 %t", replayed, tt.replayed)
+			}
+			if tt.replayed && (w.Body.String() != first.Body.String() || w.Header().Get("Location") != "/items/1") {
+				t.Errorf("重放响应 %s Location %q，期望与首次响应相同", w.Body.String(), w.Header().Get("Location"))
+			}
+			if calls != tt.calls {
+				t.Errorf("处理器执行 %d 次，期望 %d 次", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyRetryAfterFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(c *gin.Context)
+	}{
+		{"5xx响应", func(c *gin.Context) { c.JSON(http.StatusInternalServerError, gin.H{}) }},
+		{"处理器panic", func(c *gin.Context) { panic("boom") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			r := idempotencyRouter(t, func(c *gin.Context) {
+				calls++
+				if calls == 1 {
+					tt.fail(c)
+					return
+				}
+				c.JSON(http.StatusCreated, gin.H{"id": calls})
+			})
+
+			if w := postItem(r, "key", `{}`); w.Code != http.StatusInternalServerError {
+				t.Fatalf("首次请求响应 %d，期望 500", w.Code)
+			}
+			// 失败的请求不保存响应，使用相同的键重试时重新处理
+			w := postItem(r, "key", `{}`)
+			if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" || calls != 2 {
+				t.Fatalf("重试响应 %d %s，处理器执行 %d 次，期望重新处理", w.Code, errorCode(w), calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/dingdinglz/test-blog/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Locale 语言协商中间件，根据Accept-Language选择响应语言
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Match(c.GetHeader("Accept-Language"))
+
+		// 将语言存入Context，供统一响应使用
+		c.Set(i18n.ContextKey, locale)
+		c.Header("Content-Language", locale)
+		c.Header("Vary", "Accept-Language")
+
+		c.Next()
+	}
+}
@@ -4,22 +4,99 @@ import (
 	"gorm.io/gorm"
 )
 
+// 文章内容格式
+const (
+	ContentFormatMarkdown = "markdown"
+	ContentFormatHTML     = "html"
+	ContentFormatPlain    = "plain"
+)
+
+// 文章状态
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusPublished = "published"
+)
+
 // Article 文章模型
 type Article struct {
 	gorm.Model
-	Title   string `gorm:"not null" json:"title"`
-	Content string `gorm:"type:text;not null" json:"content"`
-	UserID  uint   `gorm:"not null" json:"user_id"`
-	User    User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Title         string  `gorm:"not null" json:"title"`
+	Slug          string  `gorm:"size:255;uniqueIndex;default:null" json:"slug"`
+	Content       string  `gorm:"type:text;not null" json:"content"`
+	ContentFormat string  `gorm:"not null;default:markdown" json:"content_format"`
+	ContentHTML   string  `gorm:"type:text" json:"-"` // 渲染结果缓存，内容变化时重新生成
+	Status        string  `gorm:"size:16;not null;default:published;index" json:"status"`
+	Version       uint    `gorm:"not null;default:1" json:"version"` // 每次修改加1，用于检测并发修改
+	CoverImage    string  `gorm:"size:512" json:"cover_image"`
+	Excerpt       string  `gorm:"type:text" json:"excerpt"` // 作者填写的摘要，为空时根据内容自动生成
+	WordCount     int     `gorm:"not null;default:0" json:"word_count"`
+	ReadingTime   int     `gorm:"not null;default:0" json:"reading_time"` // 预计阅读分钟数，与WordCount一起在渲染时生成
+	UserID        uint    `gorm:"not null" json:"user_id"`
+	User          User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Tags          []Tag   `gorm:"many2many:article_tags" json:"tags,omitempty"`
+	Media         []Media `gorm:"many2many:article_media" json:"-"` // 内容中引用的上传文件
+}
+
+// ArticleSlug 文章的历史slug，用于重命名后的永久重定向
+type ArticleSlug struct {
+	gorm.Model
+	ArticleID uint   `gorm:"not null;index"`
+	Slug      string `gorm:"size:255;not null;uniqueIndex"`
+}
+
+// ArticleRedirect 从其它博客系统迁移时保留的旧文章地址，访问时永久重定向到文章页面
+type ArticleRedirect struct {
+	gorm.Model
+	ArticleID uint   `gorm:"not null;index"`
+	Path      string `gorm:"size:512;not null;uniqueIndex"` // 站内路径，可带查询参数，如 /2020/01/hello/ 或 /?p=12
+}
+
+// TrashArticleResponse 回收站中的文章，PurgeAt为到期永久删除的时间
+type TrashArticleResponse struct {
+	ID        uint   `json:"id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	Excerpt   string `json:"excerpt"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	DeletedAt string `json:"deleted_at"`
+	PurgeAt   string `json:"purge_at"`
 }
 
-// ArticleResponse 文章响应结构
+// ArticleResponse 文章响应结构，接口按 ?fields= 和 ?include= 只返回其中部分字段
 type ArticleResponse struct {
-	ID        uint         `json:"id"`
-	Title     string       `json:"title"`
-	Content   string       `json:"content"`
-	UserID    uint         `json:"user_id"`
-	Author    UserResponse `json:"author,omitempty"`
-	CreatedAt string       `json:"created_at"`
-	UpdatedAt string       `json:"updated_at"`
+	ID            uint           `json:"id"`
+	Title         string         `json:"title"`
+	Slug          string         `json:"slug"`
+	Content       string         `json:"content"`
+	ContentFormat string         `json:"content_format"`
+	ContentHTML   string         `json:"content_html"`
+	Excerpt       string         `json:"excerpt"`
+	CoverImage    string         `json:"cover_image"`
+	WordCount     int            `json:"word_count"`
+	ReadingTime   int            `json:"reading_time"`
+	Status        string         `json:"status"`
+	Version       uint           `json:"version"`
+	UserID        uint           `json:"user_id"`
+	Author        AuthorResponse `json:"author"`
+	Tags          []TagResponse  `json:"tags"`
+	CreatedAt     string         `json:"created_at"`
+	UpdatedAt     string         `json:"updated_at"`
+}
+
+// BatchItemResponse 批量操作中单篇文章的结果，Status为 ok、failed 或 skipped（原子执行时因其他文章失败而未生效）
+type BatchItemResponse struct {
+	ID      uint   `json:"id"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// BatchResponse 批量操作的结果
+type BatchResponse struct {
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Skipped   int                 `json:"skipped"`
+	Results   []BatchItemResponse `json:"results"`
 }
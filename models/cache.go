@@ -0,0 +1,12 @@
+package models
+
+// CacheStatsResponse 读缓存的命中统计，统计从服务启动开始
+type CacheStatsResponse struct {
+	Driver    string  `json:"driver"` // memory 或 none
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRate   float64 `json:"hit_rate"`  // 命中次数占查询次数的比例，没有查询时为0
+	Evictions uint64  `json:"evictions"` // 因容量不足被淘汰的数量
+	Entries   int     `json:"entries"`
+	Capacity  int     `json:"capacity"`
+}
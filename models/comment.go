@@ -0,0 +1,35 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// 评论状态
+const (
+	CommentStatusApproved = "approved"
+	CommentStatusPending  = "pending"
+)
+
+// Comment 评论模型，访客评论没有关联用户
+type Comment struct {
+	gorm.Model
+	ArticleID   uint   `gorm:"not null;index" json:"article_id"`
+	ParentID    *uint  `gorm:"index" json:"parent_id"`
+	UserID      *uint  `gorm:"index" json:"user_id"`
+	AuthorName  string `gorm:"size:255" json:"author_name"`
+	AuthorEmail string `gorm:"size:255" json:"-"`
+	AuthorURL   string `gorm:"size:512" json:"author_url"`
+	Content     string `gorm:"type:text;not null" json:"content"` // 已清洗的HTML
+	Status      string `gorm:"size:16;not null;default:approved;index" json:"status"`
+}
+
+// CommentResponse 评论响应结构（不包含邮箱）
+type CommentResponse struct {
+	ID         uint   `json:"id"`
+	ParentID   *uint  `json:"parent_id"`
+	UserID     *uint  `json:"user_id"`
+	AuthorName string `json:"author_name"`
+	AuthorURL  string `json:"author_url"`
+	Content    string `json:"content"`
+	CreatedAt  string `json:"created_at"`
+}
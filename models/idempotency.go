@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey 带 Idempotency-Key 请求头的请求及其响应，同一用户使用相同的键重试时直接返回保存的响应
+type IdempotencyKey struct {
+	ID          uint              `gorm:"primarykey"`
+	UserID      uint              `gorm:"not null;uniqueIndex:idx_idempotency_key"`
+	Key         string            `gorm:"size:255;not null;uniqueIndex:idx_idempotency_key"`
+	Fingerprint string            `gorm:"size:64;not null"`   // 请求方法、地址和请求体的SHA256
+	Status      int               `gorm:"not null;default:0"` // 响应的HTTP状态码，为0时请求仍在处理中
+	Header      map[string]string `gorm:"type:text;serializer:json"`
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// 导入记录的类型
+const (
+	ImportKindUser    = "user"
+	ImportKindArticle = "article"
+	ImportKindComment = "comment"
+)
+
+// ImportedItem 外部数据与本地记录的对应关系，重复导入时跳过已导入的数据
+type ImportedItem struct {
+	gorm.Model
+	Source     string `gorm:"size:255;not null;uniqueIndex:idx_imported_item"` // 数据来源，如WordPress站点地址
+	Kind       string `gorm:"size:16;not null;uniqueIndex:idx_imported_item"`
+	ExternalID string `gorm:"size:255;not null;uniqueIndex:idx_imported_item"`
+	LocalID    uint   `gorm:"not null"`
+}
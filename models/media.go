@@ -0,0 +1,74 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// 文件处理状态，图片上传后等待生成缩放版本
+const (
+	MediaStatusPending = "pending"
+	MediaStatusReady   = "ready"
+	MediaStatusFailed  = "failed"
+)
+
+// Media 上传的文件，Key为文件在存储后端中的路径，Articles为内容中引用了该文件的文章
+type Media struct {
+	gorm.Model
+	UserID   uint      `gorm:"not null;index" json:"user_id"`
+	User     User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Key      string    `gorm:"size:255;not null;uniqueIndex" json:"key"`
+	Filename string    `gorm:"size:255" json:"filename"`
+	MimeType string    `gorm:"size:127;not null" json:"mime_type"`
+	Size     int64     `gorm:"not null" json:"size"`
+	Articles []Article `gorm:"many2many:article_media" json:"articles,omitempty"`
+
+	// 图片信息，由后台处理生成
+	Status        string         `gorm:"size:16;not null;default:pending;index" json:"status"`
+	Width         int            `json:"width"`
+	Height        int            `json:"height"`
+	Blurhash      string         `gorm:"size:64" json:"blurhash"`
+	DominantColor string         `gorm:"size:7" json:"dominant_color"` // 如 #a1b2c3
+	Variants      []MediaVariant `gorm:"foreignKey:MediaID" json:"variants,omitempty"`
+}
+
+// MediaVariant 图片的缩放版本
+type MediaVariant struct {
+	gorm.Model
+	MediaID uint   `gorm:"not null;index" json:"media_id"`
+	Name    string `gorm:"size:32;not null" json:"name"`
+	Format  string `gorm:"size:16;not null" json:"format"` // jpeg、png 或 webp
+	Crop    bool   `gorm:"not null;default:false" json:"crop"`
+	Key     string `gorm:"size:255;not null;uniqueIndex" json:"key"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Size    int64  `json:"size"`
+}
+
+// MediaResponse 文件响应结构，Srcset可直接用于img标签的srcset属性
+type MediaResponse struct {
+	ID            uint                   `json:"id"`
+	URL           string                 `json:"url"`
+	Filename      string                 `json:"filename"`
+	MimeType      string                 `json:"mime_type"`
+	Size          int64                  `json:"size"`
+	UsageCount    int                    `json:"usage_count"`
+	Status        string                 `json:"status"`
+	Width         int                    `json:"width,omitempty"`
+	Height        int                    `json:"height,omitempty"`
+	Blurhash      string                 `json:"blurhash,omitempty"`
+	DominantColor string                 `json:"dominant_color,omitempty"`
+	Variants      []MediaVariantResponse `json:"variants"`
+	Srcset        string                 `json:"srcset,omitempty"`
+	SrcsetWebP    string                 `json:"srcset_webp,omitempty"`
+	CreatedAt     string                 `json:"created_at"`
+}
+
+// MediaVariantResponse 图片缩放版本响应结构
+type MediaVariantResponse struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Tag 标签模型
+type Tag struct {
+	gorm.Model
+	Name     string    `gorm:"size:64;uniqueIndex;not null" json:"name"`
+	Slug     string    `gorm:"size:255;uniqueIndex;not null" json:"slug"`
+	Articles []Article `gorm:"many2many:article_tags" json:"articles,omitempty"`
+}
+
+// TagResponse 标签响应结构
+type TagResponse struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
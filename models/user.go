@@ -1,22 +1,84 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+	UserRoleGhost = "ghost" // 接收已注销用户内容的匿名用户，不能登录
+)
+
 // User 用户模型
 type User struct {
 	gorm.Model
-	Username string    `gorm:"uniqueIndex;not null" json:"username"`
-	Password string    `gorm:"not null" json:"-"`
-	Email    string    `gorm:"uniqueIndex;not null" json:"email"`
-	Articles []Article `gorm:"foreignKey:UserID" json:"articles,omitempty"`
+	Username      string       `gorm:"uniqueIndex;not null" json:"username"`
+	Password      string       `gorm:"not null" json:"-"`
+	Email         string       `gorm:"uniqueIndex;not null" json:"-"` // 不直接序列化，按 UserResponse 或 AuthorResponse 的规则返回
+	DisplayName   string       `gorm:"size:64" json:"display_name"`
+	Bio           string       `gorm:"size:500" json:"bio"`
+	Avatar        string       `gorm:"size:512" json:"avatar"`       // 头像图片地址
+	AvatarMediaID *uint        `gorm:"index" json:"avatar_media_id"` // 使用上传的图片作为头像时对应的文件ID
+	Website       string       `gorm:"size:255" json:"website"`
+	SocialLinks   []SocialLink `gorm:"type:text;serializer:json" json:"social_links"`
+	Role          string       `gorm:"size:16;not null;default:user" json:"role"`
+	ShowEmail     bool         `gorm:"not null;default:false" json:"show_email"` // 是否在公开的作者资料中显示邮箱
+	DeleteAt      *time.Time   `gorm:"index" json:"delete_at"`                   // 申请注销后计划删除账户的时间
+	Articles      []Article    `gorm:"foreignKey:UserID" json:"articles,omitempty"`
 }
 
-// UserResponse 用户响应结构（不包含密码）
+// SocialLink 社交账号链接
+type SocialLink struct {
+	Name string `json:"name"` // 平台名称，如 github、twitter
+	URL  string `json:"url"`
+}
+
+// Name 显示名称，未设置时使用用户名
+func (u User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.Username
+}
+
+// UserResponse 用户本人的账户信息（不包含密码），只返回给本人
 type UserResponse struct {
-	ID        uint   `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	CreatedAt string `json:"created_at"`
+	ID            uint         `json:"id"`
+	Username      string       `json:"username"`
+	Email         string       `json:"email"`
+	DisplayName   string       `json:"display_name"`
+	Bio           string       `json:"bio"`
+	Avatar        string       `json:"avatar"`          // 用户设置的头像地址
+	AvatarMediaID *uint        `json:"avatar_media_id"` // 用户设置的头像文件
+	AvatarURL     string       `json:"avatar_url"`      // 实际显示的头像，未设置时为默认头像
+	Website       string       `json:"website"`
+	SocialLinks   []SocialLink `json:"social_links"`
+	Role          string       `json:"role"`
+	ShowEmail     bool         `json:"show_email"`
+	DeleteAt      *string      `json:"delete_at"` // 申请注销后计划删除账户的时间，未申请时为null
+	CreatedAt     string       `json:"created_at"`
+}
+
+// AuthorResponse 公开的作者资料，邮箱只在作者设置公开或本人、管理员查看时返回
+type AuthorResponse struct {
+	ID          uint   `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Avatar      string `json:"avatar"` // 实际显示的头像，未设置时为默认头像
+	Bio         string `json:"bio"`
+	Email       string `json:"email,omitempty"`
+}
+
+// ProfileResponse 公开的个人主页资料，草稿数只返回给本人和管理员
+type ProfileResponse struct {
+	AuthorResponse
+	Website      string       `json:"website"`
+	SocialLinks  []SocialLink `json:"social_links"`
+	ArticleCount int64        `json:"article_count"`
+	DraftCount   *int64       `json:"draft_count,omitempty"`
+	CreatedAt    string       `json:"created_at"`
 }
@@ -1,9 +1,13 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/dingdinglz/test-blog/config"
 	"github.com/dingdinglz/test-blog/handlers"
 	"github.com/dingdinglz/test-blog/middleware"
+	"github.com/dingdinglz/test-blog/storage"
+	"github.com/dingdinglz/test-blog/web"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,8 +22,38 @@ func SetupRouter() *gin.Engine {
 	// 使用全局中间件
 	r.Use(middleware.CORS())
 	r.Use(middleware.Logger())
+	r.Use(middleware.Locale())
+	r.Use(middleware.CacheControl())
+	r.Use(middleware.Compress())
 	r.Use(gin.Recovery())
 
+	// HTML页面
+	r.GET("/", handlers.HomePage)
+	r.GET("/page/:page", handlers.HomePage)
+	r.GET("/articles/:slug", handlers.ArticlePage)
+	r.GET("/authors/:username", handlers.AuthorPage)
+	r.GET("/tags/:slug", handlers.TagPage)
+	r.StaticFS("/static", http.FS(web.StaticFS()))
+
+	// 本地存储的上传文件
+	if local, ok := storage.GetStorage().(*storage.Local); ok {
+		r.Static(local.URLPrefix, local.Dir)
+	}
+	r.NoRoute(handlers.NotFoundPage)
+
+	// 订阅源，内容很少变化，缓存压缩结果
+	r.GET("/feed.xml", middleware.CompressCache(), handlers.RSSFeed)
+	r.GET("/atom.xml", middleware.CompressCache(), handlers.AtomFeed)
+	r.GET("/feed.json", middleware.CompressCache(), handlers.JSONFeed)
+	r.GET("/authors/:username/feed.xml", middleware.CompressCache(), handlers.RSSFeed)
+	r.GET("/authors/:username/atom.xml", middleware.CompressCache(), handlers.AtomFeed)
+	r.GET("/authors/:username/feed.json", middleware.CompressCache(), handlers.JSONFeed)
+
+	// 站点地图
+	r.GET("/sitemap.xml", middleware.CompressCache(), handlers.Sitemap)
+	r.GET("/sitemap/:page", middleware.CompressCache(), handlers.SitemapPage)
+	r.GET("/robots.txt", handlers.Robots)
+
 	// API路由组
 	api := r.Group("/api")
 	{
@@ -27,10 +61,21 @@ func SetupRouter() *gin.Engine {
 		api.POST("/register", handlers.Register)
 		api.POST("/login", handlers.Login)
 
-		// 公开的文章查询接口
-		api.GET("/articles", handlers.GetAllArticles)
-		api.GET("/articles/user/:user_id", handlers.GetArticlesByUser)
-		api.GET("/articles/:id", handlers.GetArticleByID)
+		// 公开的文章和用户查询接口，登录用户可以看到自己的草稿，本人和管理员可以看到作者邮箱
+		public := api.Group("")
+		public.Use(middleware.OptionalAuthMiddleware())
+		{
+			public.GET("/articles", handlers.GetAllArticles)
+			public.GET("/articles/user/:user_id", handlers.GetArticlesByUser)
+			public.GET("/articles/slug/:slug", handlers.GetArticleBySlug)
+			public.GET("/articles/:id", handlers.GetArticleByID)
+			public.GET("/articles/:id/comments", handlers.GetArticleComments)
+			public.GET("/tags/:slug/articles", handlers.GetTagArticles)
+			public.GET("/users/:username", handlers.GetUserProfile)
+		}
+
+		// 公开的标签查询接口
+		api.GET("/tags", handlers.GetAllTags)
 
 		// 需要认证的路由
 		auth := api.Group("")
@@ -38,11 +83,33 @@ func SetupRouter() *gin.Engine {
 		{
 			// 用户相关
 			auth.GET("/user/info", handlers.GetInfo)
+			auth.GET("/user/articles", handlers.GetMyArticles)
+			auth.PUT("/user/privacy", handlers.UpdatePrivacy)
+			auth.PUT("/user/profile", handlers.UpdateProfile)
+			auth.GET("/user/export", handlers.ExportUserData)
+			auth.POST("/user/deletion", handlers.RequestAccountDeletion)
+			auth.DELETE("/user/deletion", handlers.CancelAccountDeletion)
+
+			// 回收站
+			auth.GET("/user/trash", handlers.GetTrash)
+			auth.DELETE("/user/trash", handlers.EmptyTrash)
+			auth.DELETE("/user/trash/:id", handlers.PurgeArticle)
+			auth.POST("/articles/:id/restore", handlers.RestoreArticle)
 
 			// 文章相关
-			auth.POST("/articles", handlers.CreateArticle)
+			auth.POST("/articles", middleware.Idempotency(), handlers.CreateArticle)
 			auth.PUT("/articles/:id", handlers.UpdateArticle)
+			auth.PATCH("/articles/:id", handlers.PatchArticle)
 			auth.DELETE("/articles/:id", handlers.DeleteArticle)
+			auth.POST("/articles/batch", handlers.BatchArticles)
+
+			// 文件相关
+			auth.POST("/media", middleware.BodyLimit(handlers.MaxUploadBody()), middleware.Idempotency(), handlers.UploadMedia)
+			auth.GET("/media", handlers.GetMyMedia)
+			auth.DELETE("/media/:id", handlers.DeleteMedia)
+
+			// 管理相关
+			auth.GET("/admin/cache", handlers.GetCacheStats)
 		}
 	}
 
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// 注销账户时对用户内容的处理方式
+const (
+	DeletionModeDelete    = "delete"
+	DeletionModeAnonymize = "anonymize"
+)
+
+// RequestAccountDeletion 申请注销账户，account.deletion_grace_days 天后由 PurgeAccounts 删除；已申请时保持原计划时间
+func RequestAccountDeletion(userID uint, password string) (*models.User, error) {
+	db := database.GetDB()
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证密码
+	if !utils.CheckPassword(user.Password, password) {
+		return nil, ErrWrongPassword
+	}
+
+	if user.DeleteAt != nil {
+		return user, nil
+	}
+
+	deleteAt := time.Now().AddDate(0, 0, config.AppConfig.Account.DeletionGraceDays)
+	if err := db.Model(user).Update("delete_at", deleteAt).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("申请注销失败: %w", err))
+	}
+
+	return user, nil
+}
+
+// CancelAccountDeletion 撤销注销申请
+func CancelAccountDeletion(userID uint) (*models.User, error) {
+	db := database.GetDB()
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(user).Update("delete_at", nil).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("撤销注销失败: %w", err))
+	}
+
+	return user, nil
+}
+
+// PurgeAccounts 永久删除已过注销等待期的账户，按 account.deletion_mode 删除或匿名化其内容，dryRun为true时只返回待删除的账户
+func PurgeAccounts(dryRun bool) ([]models.User, error) {
+	db := database.GetDB()
+
+	mode := config.AppConfig.Account.DeletionMode
+	if mode != DeletionModeDelete && mode != DeletionModeAnonymize {
+		return nil, fmt.Errorf("未知的注销处理方式: %s", mode)
+	}
+
+	var users []models.User
+	if err := db.Where("delete_at <= ? AND role <> ?", time.Now(), models.UserRoleGhost).
+		Order("id asc").Find(&users).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询待注销账户失败: %w", err))
+	}
+
+	if dryRun {
+		return users, nil
+	}
+
+	for i := range users {
+		if err := purgeAccount(&users[i], mode); err != nil {
+			return users[:i], fmt.Errorf("注销用户 %s 失败: %w", users[i].Username, err)
+		}
+	}
+	return users, nil
+}
+
+// StartAccountPurge 启动后台协程，每隔 account.purge_interval 小时处理一次已过注销等待期的账户
+func StartAccountPurge() {
+	interval := config.AppConfig.Account.PurgeInterval
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			users, err := PurgeAccounts(false)
+			if err != nil {
+				log.Printf("注销到期账户失败: %v\n", err)
+			}
+			if len(users) > 0 {
+				log.Printf("已注销 %d 个到期账户\n", len(users))
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// purgeAccount 删除或匿名化用户的内容，然后永久删除用户记录；已被软删除的文章和评论一并永久删除
+func purgeAccount(user *models.User, mode string) error {
+	db := database.GetDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var articleIDs, commentIDs []uint
+		articles := tx.Unscoped().Model(&models.Article{}).Where("user_id = ?", user.ID)
+		comments := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", user.ID)
+		if mode == DeletionModeAnonymize {
+			// 保留未删除的内容，只清除已软删除的内容
+			articles = articles.Where("deleted_at IS NOT NULL")
+			comments = comments.Where("deleted_at IS NOT NULL")
+		}
+		if err := articles.Pluck("id", &articleIDs).Error; err != nil {
+			return err
+		}
+		if err := comments.Pluck("id", &commentIDs).Error; err != nil {
+			return err
+		}
+		if err := purgeArticles(tx, articleIDs); err != nil {
+			return err
+		}
+		if err := purgeComments(tx, commentIDs); err != nil {
+			return err
+		}
+
+		if mode == DeletionModeAnonymize {
+			if err := transferToGhost(tx, user); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Where("kind = ? AND local_id = ?", models.ImportKindUser, user.ID).
+			Delete(&models.ImportedItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(user).Error
+	})
+	if err != nil {
+		return utils.ErrInternal.Wrap(err)
+	}
+	invalidateArticles()
+
+	// 删除剩余的文件，匿名化时仍被文章引用的文件已转移给匿名用户
+	var media []models.Media
+	if err := db.Where("user_id = ?", user.ID).Find(&media).Error; err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("查询用户文件失败: %w", err))
+	}
+	for i := range media {
+		if err := deleteMedia(db, &media[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transferToGhost 把用户的文章、评论和文章引用的文件转移给匿名用户，评论中的个人信息一并清除
+func transferToGhost(tx *gorm.DB, user *models.User) error {
+	ghost, err := ghostUser(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Article{}).Where("user_id = ?", user.ID).
+		UpdateColumn("user_id", ghost.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Comment{}).Where("user_id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"user_id":      ghost.ID,
+		"author_name":  ghost.Name(),
+		"author_email": "",
+		"author_url":   "",
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Media{}).Where("user_id = ?", user.ID).
+		Where("EXISTS (SELECT 1 FROM article_media WHERE article_media.media_id = media.id)").
+		UpdateColumn("user_id", ghost.ID).Error
+}
+
+// ghostUser 获取匿名用户，不存在时创建；用户名被普通用户占用时返回错误
+func ghostUser(tx *gorm.DB) (*models.User, error) {
+	username := config.AppConfig.Account.GhostUsername
+
+	var ghost models.User
+	err := tx.Where("username = ?", username).First(&ghost).Error
+	if err == nil {
+		if ghost.Role != models.UserRoleGhost {
+			return nil, fmt.Errorf("匿名用户名 %s 已被普通用户使用", username)
+		}
+		return &ghost, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	ghost = models.User{
+		Username: username,
+		Password: password,
+		Email:    username + "@ghost.invalid",
+		Role:     models.UserRoleGhost,
+	}
+	if err := tx.Create(&ghost).Error; err != nil {
+		return nil, err
+	}
+	return &ghost, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+)
+
+// expireDeletion 申请注销并把计划删除时间改为已到期
+func expireDeletion(t *testing.T, user *models.User) {
+	t.Helper()
+	if _, err := RequestAccountDeletion(user.ID, "secret123"); err != nil {
+		t.Fatalf("申请注销失败: %v", err)
+	}
+	database.GetDB().Model(user).Update("delete_at", time.Now().Add(-time.Minute))
+}
+
+// createComment 在文章下创建评论
+func createComment(t *testing.T, articleID uint, user *models.User) *models.Comment {
+	t.Helper()
+	comment := &models.Comment{
+		ArticleID:   articleID,
+		UserID:      &user.ID,
+		AuthorName:  user.Username,
+		AuthorEmail: user.Email,
+		AuthorURL:   "https://example.com/" + user.Username,
+		Content:     "<p>评论</p>",
+	}
+	if err := database.GetDB().Create(comment).Error; err != nil {
+		t.Fatalf("创建评论失败: %v", err)
+	}
+	return comment
+}
+
+func TestRequestAccountDeletion(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+
+	if _, err := RequestAccountDeletion(alice.ID, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("密码错误时错误 %v，期望 ErrWrongPassword", err)
+	}
+
+	first, err := RequestAccountDeletion(alice.ID, "secret123")
+	if err != nil {
+		t.Fatalf("申请注销失败: %v", err)
+	}
+	wantAt := time.Now().AddDate(0, 0, config.AppConfig.Account.DeletionGraceDays)
+	if first.DeleteAt == nil || first.DeleteAt.Sub(wantAt).Abs() > time.Minute {
+		t.Fatalf("计划删除时间 %v，期望约为 %v", first.DeleteAt, wantAt)
+	}
+
+	// 重复申请不推迟删除时间
+	second, err := RequestAccountDeletion(alice.ID, "secret123")
+	if err != nil || !second.DeleteAt.Equal(*first.DeleteAt) {
+		t.Errorf("重复申请后删除时间 %v，期望保持 %v", second.DeleteAt, first.DeleteAt)
+	}
+
+	// 等待期内的账户不会被注销
+	if users, err := PurgeAccounts(false); err != nil || len(users) != 0 {
+		t.Errorf("等待期内注销了 %d 个账户，错误 %v", len(users), err)
+	}
+
+	if user, err := CancelAccountDeletion(alice.ID); err != nil || user.DeleteAt != nil {
+		t.Errorf("撤销注销后 delete_at = %v，错误 %v", user.DeleteAt, err)
+	}
+}
+
+func TestPurgeAccounts(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		// keepArticle 注销后文章是否保留（转移给匿名用户）
+		keepArticle bool
+	}{
+		{"删除全部内容", DeletionModeDelete, false},
+		{"匿名化", DeletionModeAnonymize, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupDB(t)
+			previous := config.AppConfig.Account.DeletionMode
+			config.AppConfig.Account.DeletionMode = tt.mode
+			t.Cleanup(func() { config.AppConfig.Account.DeletionMode = previous })
+
+			alice := createUser(t, "alice")
+			bob := createUser(t, "bob")
+			article := createArticle(t, alice.ID, "alice's", "go")
+			trashed := createArticle(t, alice.ID, "trashed")
+			if err := DeleteArticle(trashed.ID, alice.ID, nil); err != nil {
+				t.Fatalf("删除文章失败: %v", err)
+			}
+			bobsArticle := createArticle(t, bob.ID, "bob's")
+			comment := createComment(t, bobsArticle.ID, alice)
+			expireDeletion(t, alice)
+
+			// dryRun 只返回待注销的账户
+			users, err := PurgeAccounts(true)
+			if err != nil || len(users) != 1 || users[0].ID != alice.ID {
+				t.Fatalf("dryRun 返回 %v，错误 %v", users, err)
+			}
+			if _, err := GetUserByID(alice.ID); err != nil {
+				t.Fatalf("dryRun 删除了用户")
+			}
+
+			if users, err = PurgeAccounts(false); err != nil || len(users) != 1 {
+				t.Fatalf("PurgeAccounts 返回 %d 个账户，错误 %v", len(users), err)
+			}
+
+			db := database.GetDB()
+			if _, err := GetUserByID(alice.ID); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("注销后用户仍存在: %v", err)
+			}
+			if _, err := GetUserByID(bob.ID); err != nil {
+				t.Errorf("其他用户被注销: %v", err)
+			}
+
+			// 回收站中的文章两种方式都永久删除
+			var count int64
+			db.Unscoped().Model(&models.Article{}).Where("id = ?", trashed.ID).Count(&count)
+			if count != 0 {
+				t.Errorf("回收站中的文章未永久删除")
+			}
+
+			var kept models.Article
+			err = db.Unscoped().First(&kept, article.ID).Error
+			if (err == nil) != tt.keepArticle {
+				t.Fatalf("文章保留 %t，期望 %t", err == nil, tt.keepArticle)
+			}
+			var keptComment models.Comment
+			err = db.Unscoped().First(&keptComment, comment.ID).Error
+			if (err == nil) != tt.keepArticle {
+				t.Fatalf("评论保留 %t，期望 %t", err == nil, tt.keepArticle)
+			}
+			if !tt.keepArticle {
+				return
+			}
+
+			ghost, err := GetUserByUsername(config.AppConfig.Account.GhostUsername)
+			if err != nil {
+				t.Fatalf("未创建匿名用户: %v", err)
+			}
+			if ghost.Role != models.UserRoleGhost {
+				t.Errorf("匿名用户角色 %s，期望 ghost", ghost.Role)
+			}
+			if kept.UserID != ghost.ID {
+				t.Errorf("文章作者 %d，期望匿名用户 %d", kept.UserID, ghost.ID)
+			}
+			if keptComment.UserID == nil || *keptComment.UserID != ghost.ID ||
+				keptComment.AuthorName != ghost.Username || keptComment.AuthorEmail != "" || keptComment.AuthorURL != "" {
+				t.Errorf("评论未匿名化: %+v", keptComment)
+			}
+
+			// 匿名用户本身不会被当作到期账户处理
+			db.Model(ghost).Update("delete_at", time.Now().Add(-time.Minute))
+			if users, err := PurgeAccounts(false); err != nil || len(users) != 0 {
+				t.Errorf("匿名用户被注销: %v %v", users, err)
+			}
+		})
+	}
+}
+
+func TestPurgeAccountsGhostUsernameTaken(t *testing.T) {
+	setupDB(t)
+	previousMode, previousGhost := config.AppConfig.Account.DeletionMode, config.AppConfig.Account.GhostUsername
+	config.AppConfig.Account.DeletionMode = DeletionModeAnonymize
+	config.AppConfig.Account.GhostUsername = "ghost"
+	t.Cleanup(func() {
+		config.AppConfig.Account.DeletionMode = previousMode
+		config.AppConfig.Account.GhostUsername = previousGhost
+	})
+
+	alice := createUser(t, "alice")
+	createArticle(t, alice.ID, "alice's")
+	// 配置匿名用户名之前注册的普通用户
+	config.AppConfig.Account.GhostUsername = "someone"
+	createUser(t, "ghost")
+	config.AppConfig.Account.GhostUsername = "ghost"
+	expireDeletion(t, alice)
+
+	users, err := PurgeAccounts(false)
+	if err == nil || len(users) != 0 {
+		t.Fatalf("匿名用户名被占用时返回 %v，错误 %v，期望失败", users, err)
+	}
+	if _, err := GetUserByID(alice.ID); err != nil {
+		t.Errorf("失败后用户被删除: %v", err)
+	}
+}
@@ -2,125 +2,522 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/dingdinglz/test-blog/cache"
+	"github.com/dingdinglz/test-blog/config"
 	"github.com/dingdinglz/test-blog/database"
 	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
 	"gorm.io/gorm"
 )
 
+// renderArticle 渲染文章内容并写入缓存字段，同时统计字数和阅读时间
+func renderArticle(article *models.Article) error {
+	if article.ContentFormat == "" {
+		article.ContentFormat = models.ContentFormatMarkdown
+	}
+
+	contentHTML, err := utils.RenderContent(article.ContentFormat, article.Content)
+	if err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("渲染文章失败: %w", err))
+	}
+	article.ContentHTML = contentHTML
+
+	words, cjk := utils.CountWords(utils.PlainText(contentHTML))
+	article.WordCount = words + cjk
+	article.ReadingTime = utils.ReadingTime(words, cjk)
+	return nil
+}
+
+// ensureRendered 为尚未缓存渲染结果或阅读时间的旧文章补充渲染，不更新UpdatedAt
+func ensureRendered(db *gorm.DB, articles ...*models.Article) {
+	for _, article := range articles {
+		if (article.ContentHTML != "" && article.ReadingTime != 0) || article.Content == "" {
+			continue
+		}
+		if err := renderArticle(article); err != nil {
+			continue
+		}
+		db.Model(article).UpdateColumns(map[string]interface{}{
+			"content_html": article.ContentHTML,
+			"word_count":   article.WordCount,
+			"reading_time": article.ReadingTime,
+		})
+	}
+}
+
+// ArticleExcerpt 文章摘要，作者未填写时截取内容开头，长度与 feed.summary_length 相同
+func ArticleExcerpt(article *models.Article) string {
+	if article.Excerpt != "" {
+		return article.Excerpt
+	}
+	return utils.Summarize(article.ContentHTML, config.AppConfig.Feed.SummaryLength)
+}
+
+// applyArticleMeta 设置封面图片和摘要，nil表示保留原值
+func applyArticleMeta(article *models.Article, coverImage, excerpt *string) error {
+	if coverImage != nil {
+		cover := strings.TrimSpace(*coverImage)
+		if cover != "" && !utils.ValidImageURL(cover) {
+			return ErrInvalidCover
+		}
+		article.CoverImage = cover
+	}
+	if excerpt != nil {
+		article.Excerpt = strings.TrimSpace(*excerpt)
+	}
+	return nil
+}
+
+// published 只查询已发布的文章
+func published(db *gorm.DB) *gorm.DB {
+	return db.Where("articles.status = ?", models.ArticleStatusPublished)
+}
+
+// ArticleLoad 查询文章列表时加载的关联和内容，只加载响应需要的部分
+type ArticleLoad struct {
+	Author  bool
+	Tags    bool
+	Content bool // 为false时不查询原始内容，渲染后的内容仍用于生成摘要
+}
+
+// LoadAll 加载文章的全部关联和内容
+var LoadAll = ArticleLoad{Author: true, Tags: true, Content: true}
+
+// scope 按需预加载关联
+func (l ArticleLoad) scope(db *gorm.DB) *gorm.DB {
+	if l.Author {
+		db = db.Preload("User")
+	}
+	if l.Tags {
+		db = db.Preload("Tags")
+	}
+	if !l.Content {
+		db = db.Omit("content")
+	}
+	return db
+}
+
+// ArticleVisible 草稿只对作者本人可见，viewerID为0表示未登录
+func ArticleVisible(article *models.Article, viewerID uint) bool {
+	return article.Status != models.ArticleStatusDraft || (viewerID != 0 && article.UserID == viewerID)
+}
+
+// ArticleInput 文章的可编辑字段
+type ArticleInput struct {
+	Title         string
+	Content       string
+	ContentFormat string   // 为空时创建使用markdown，更新保留原有格式
+	Slug          string   // 为空时创建根据标题自动生成，更新保留原有slug
+	Tags          []string // 为nil时更新保留原有标签，空切片清空标签
+	Status        string   // 为空时创建为已发布，更新保留原有状态
+	CoverImage    *string  // 为nil时更新保留原有封面，空字符串清除封面
+	Excerpt       *string  // 为nil时更新保留原有摘要，空字符串改为自动生成
+}
+
 // CreateArticle 创建文章
-func CreateArticle(title, content string, userID uint) (*models.Article, error) {
+func CreateArticle(input ArticleInput, userID uint) (*models.Article, error) {
 	db := database.GetDB()
 
 	article := &models.Article{
-		Title:   title,
-		Content: content,
-		UserID:  userID,
+		Title:         input.Title,
+		Content:       input.Content,
+		ContentFormat: input.ContentFormat,
+		Status:        input.Status,
+		UserID:        userID,
+	}
+	if article.Status == "" {
+		article.Status = models.ArticleStatusPublished
+	}
+	if err := applyArticleMeta(article, input.CoverImage, input.Excerpt); err != nil {
+		return nil, err
 	}
 
-	if err := db.Create(article).Error; err != nil {
-		return nil, errors.New("创建文章失败")
+	// 生成或校验slug
+	if input.Slug != "" {
+		if err := checkSlug(db, input.Slug, 0); err != nil {
+			return nil, err
+		}
+		article.Slug = input.Slug
+	} else {
+		slug, err := uniqueSlug(db, input.Title, 0)
+		if err != nil {
+			return nil, err
+		}
+		article.Slug = slug
+	}
+
+	if err := renderArticle(article); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tags, err := resolveTags(tx, input.Tags)
+		if err != nil {
+			return err
+		}
+		article.Tags = tags
+
+		if err := tx.Create(article).Error; err != nil {
+			return utils.ErrInternal.Wrap(fmt.Errorf("创建文章失败: %w", err))
+		}
+		if err := syncArticleMedia(tx, article); err != nil {
+			return utils.ErrInternal.Wrap(fmt.Errorf("更新文章引用的文件失败: %w", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	invalidateArticles(article.ID)
+
 	// 预加载用户信息
-	db.Preload("User").First(article, article.ID)
+	db.Preload("User").Preload("Tags").First(article, article.ID)
 
 	return article, nil
 }
 
-// GetAllArticles 获取所有文章
-func GetAllArticles() ([]models.Article, error) {
-	db := database.GetDB()
+// GetAllArticles 获取所有已发布文章
+func GetAllArticles(load ArticleLoad) ([]models.Article, error) {
+	return cachedArticles("articles:all:"+load.key(), func() ([]models.Article, error) {
+		db := database.GetDB()
 
-	var articles []models.Article
-	if err := db.Preload("User").Order("created_at desc").Find(&articles).Error; err != nil {
-		return nil, errors.New("获取文章列表失败")
+		var articles []models.Article
+		if err := db.Scopes(published, load.scope).Order("created_at desc").Find(&articles).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取文章列表失败: %w", err))
+		}
+
+		for i := range articles {
+			ensureRendered(db, &articles[i])
+		}
+
+		return articles, nil
+	})
+}
+
+// GetArticlesPage 分页获取已发布文章，page从1开始，同时返回文章总数
+func GetArticlesPage(page, pageSize int) ([]models.Article, int64, error) {
+	value, err := cache.Load(fmt.Sprintf("articles:page:%d:%d", page, pageSize), func() (interface{}, error) {
+		db := database.GetDB()
+
+		var total int64
+		if err := db.Model(&models.Article{}).Scopes(published).Count(&total).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("统计文章数量失败: %w", err))
+		}
+
+		var articles []models.Article
+		if err := db.Scopes(published).Preload("User").Preload("Tags").Order("created_at desc").
+			Offset((page - 1) * pageSize).Limit(pageSize).Find(&articles).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取文章列表失败: %w", err))
+		}
+
+		for i := range articles {
+			ensureRendered(db, &articles[i])
+		}
+
+		return articlePage{articles: articles, total: total}, nil
+	})
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return articles, nil
+	result := value.(articlePage)
+	return copyArticles(result.articles), result.total, nil
 }
 
-// GetUserArticles 获取指定用户的文章
-func GetUserArticles(userID uint) ([]models.Article, error) {
+// GetLatestArticles 获取最新的limit篇已发布文章
+func GetLatestArticles(limit int) ([]models.Article, error) {
+	return cachedArticles(fmt.Sprintf("articles:latest:%d", limit), func() ([]models.Article, error) {
+		db := database.GetDB()
+
+		var articles []models.Article
+		if err := db.Scopes(published).Preload("User").Preload("Tags").Order("created_at desc").Limit(limit).Find(&articles).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取文章列表失败: %w", err))
+		}
+
+		for i := range articles {
+			ensureRendered(db, &articles[i])
+		}
+
+		return articles, nil
+	})
+}
+
+// GetUserArticles 获取指定用户的已发布文章
+func GetUserArticles(userID uint, load ArticleLoad) ([]models.Article, error) {
+	return cachedArticles(fmt.Sprintf("articles:user:%d:%s", userID, load.key()), func() ([]models.Article, error) {
+		db := database.GetDB()
+
+		var articles []models.Article
+		if err := db.Scopes(published, load.scope).Where("user_id = ?", userID).Order("created_at desc").Find(&articles).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取用户文章列表失败: %w", err))
+		}
+
+		for i := range articles {
+			ensureRendered(db, &articles[i])
+		}
+
+		return articles, nil
+	})
+}
+
+// GetLatestUserArticles 获取指定用户最新的已发布文章
+func GetLatestUserArticles(userID uint, limit int) ([]models.Article, error) {
+	return cachedArticles(fmt.Sprintf("articles:user:%d:latest:%d", userID, limit), func() ([]models.Article, error) {
+		db := database.GetDB()
+
+		var articles []models.Article
+		if err := db.Scopes(published).Preload("User").Preload("Tags").Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&articles).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取用户文章列表失败: %w", err))
+		}
+
+		for i := range articles {
+			ensureRendered(db, &articles[i])
+		}
+
+		return articles, nil
+	})
+}
+
+// GetOwnArticles 获取用户自己的全部文章，包括草稿
+func GetOwnArticles(userID uint, load ArticleLoad) ([]models.Article, error) {
 	db := database.GetDB()
 
 	var articles []models.Article
-	if err := db.Preload("User").Where("user_id = ?", userID).Order("created_at desc").Find(&articles).Error; err != nil {
-		return nil, errors.New("获取用户文章列表失败")
+	if err := db.Scopes(load.scope).Where("user_id = ?", userID).Order("created_at desc").Find(&articles).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取用户文章列表失败: %w", err))
+	}
+
+	for i := range articles {
+		ensureRendered(db, &articles[i])
 	}
 
 	return articles, nil
 }
 
-// GetArticleByID 根据ID获取文章
+// GetArticleByID 根据ID获取文章，包括草稿，调用方需使用 ArticleVisible 判断可见性
 func GetArticleByID(articleID uint) (*models.Article, error) {
-	db := database.GetDB()
+	return cachedArticle(articleKey(articleID), func() (*models.Article, error) {
+		db := database.GetDB()
 
-	var article models.Article
-	if err := db.Preload("User").First(&article, articleID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, errors.New("文章不存在")
+		var article models.Article
+		if err := db.Preload("User").Preload("Tags").First(&article, articleID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrArticleNotFound
+			}
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询文章失败: %w", err))
 		}
-		return nil, errors.New("查询文章失败")
+
+		ensureRendered(db, &article)
+
+		return &article, nil
+	})
+}
+
+// checkArticleOwner 修改、删除文章的权限规则：只有作者本人可以操作
+func checkArticleOwner(article *models.Article, userID uint) error {
+	if article.UserID != userID {
+		return ErrArticleForbidden
 	}
+	return nil
+}
 
-	return &article, nil
+// checkChangeAuthor 更换文章作者的权限规则：只有管理员可以操作
+func checkChangeAuthor(viewer Viewer) error {
+	if !viewer.Admin {
+		return utils.ErrForbidden
+	}
+	return nil
+}
+
+// GetEditableArticle 获取用户可以修改的文章，包含标签
+func GetEditableArticle(articleID, userID uint) (*models.Article, error) {
+	article, err := GetArticleByID(articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查权限
+	if err := checkArticleOwner(article, userID); err != nil {
+		return nil, err
+	}
+
+	return article, nil
 }
 
-// UpdateArticle 更新文章
-func UpdateArticle(articleID, userID uint, title, content string) (*models.Article, error) {
+// UpdateArticle 更新文章，修改slug时旧slug保留为重定向；version为客户端持有的版本号，与当前版本不一致时返回 ErrVersionConflict，
+// 为nil时不检查（If-Match: *）
+func UpdateArticle(articleID, userID uint, version *uint, input ArticleInput) (*models.Article, error) {
 	db := database.GetDB()
 
 	// 查找文章
 	var article models.Article
 	if err := db.First(&article, articleID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, errors.New("文章不存在")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
 		}
-		return nil, errors.New("查询文章失败")
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询文章失败: %w", err))
 	}
 
 	// 检查权限
-	if article.UserID != userID {
-		return nil, errors.New("无权修改此文章")
+	if err := checkArticleOwner(&article, userID); err != nil {
+		return nil, err
+	}
+	if version != nil && article.Version != *version {
+		return nil, ErrVersionConflict
+	}
+
+	if input.Slug != "" && input.Slug != article.Slug {
+		if err := checkSlug(db, input.Slug, article.ID); err != nil {
+			return nil, err
+		}
 	}
 
 	// 更新文章
-	article.Title = title
-	article.Content = content
+	article.Title = input.Title
+	article.Content = input.Content
+	if input.ContentFormat != "" {
+		article.ContentFormat = input.ContentFormat
+	}
+	if input.Status != "" {
+		article.Status = input.Status
+	}
+	if err := applyArticleMeta(&article, input.CoverImage, input.Excerpt); err != nil {
+		return nil, err
+	}
+
+	if err := renderArticle(&article); err != nil {
+		return nil, err
+	}
 
-	if err := db.Save(&article).Error; err != nil {
-		return nil, errors.New("更新文章失败")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &article); err != nil {
+			return err
+		}
+		if input.Slug != "" {
+			if err := changeSlug(tx, &article, input.Slug); err != nil {
+				return utils.ErrInternal.Wrap(fmt.Errorf("更新slug失败: %w", err))
+			}
+		}
+		if input.Tags != nil {
+			tags, err := resolveTags(tx, input.Tags)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&article).Association("Tags").Replace(tags); err != nil {
+				return utils.ErrInternal.Wrap(fmt.Errorf("更新标签失败: %w", err))
+			}
+		}
+		if err := tx.Save(&article).Error; err != nil {
+			return utils.ErrInternal.Wrap(fmt.Errorf("更新文章失败: %w", err))
+		}
+		if err := syncArticleMedia(tx, &article); err != nil {
+			return utils.ErrInternal.Wrap(fmt.Errorf("更新文章引用的文件失败: %w", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	invalidateArticles(article.ID)
+
 	// 预加载用户信息
-	db.Preload("User").First(&article, article.ID)
+	db.Preload("User").Preload("Tags").First(&article, article.ID)
 
 	return &article, nil
 }
 
-// DeleteArticle 删除文章
-func DeleteArticle(articleID, userID uint) error {
+// DeleteArticle 删除文章，文章移入回收站，到期前可以恢复；version的含义同 UpdateArticle
+func DeleteArticle(articleID, userID uint, version *uint) error {
 	db := database.GetDB()
 
 	// 查找文章
 	var article models.Article
 	if err := db.First(&article, articleID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return errors.New("文章不存在")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrArticleNotFound
 		}
-		return errors.New("查询文章失败")
+		return utils.ErrInternal.Wrap(fmt.Errorf("查询文章失败: %w", err))
 	}
 
 	// 检查权限
-	if article.UserID != userID {
-		return errors.New("无权删除此文章")
+	if err := checkArticleOwner(&article, userID); err != nil {
+		return err
+	}
+
+	if version != nil && article.Version != *version {
+		return ErrVersionConflict
 	}
 
-	// 删除文章
-	if err := db.Delete(&article).Error; err != nil {
-		return errors.New("删除文章失败")
+	// 删除文章，同时增加版本号，使之后基于旧版本的修改失败
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &article); err != nil {
+			return err
+		}
+		if err := tx.Delete(&article).Error; err != nil {
+			return utils.ErrInternal.Wrap(fmt.Errorf("删除文章失败: %w", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	invalidateArticles(article.ID)
 	return nil
 }
+
+// bumpVersion 文章版本号加1并更新修改时间，期间已被其它请求修改时返回 ErrVersionConflict，需在事务中调用；
+// 只修改标签等关联的操作也依赖这里更新 updated_at，使 Last-Modified、站点地图和增量导出感知到变化
+func bumpVersion(tx *gorm.DB, article *models.Article) error {
+	now := time.Now()
+	result := tx.Model(&models.Article{}).Where("id = ? AND version = ?", article.ID, article.Version).
+		UpdateColumns(map[string]interface{}{"version": article.Version + 1, "updated_at": now})
+	if result.Error != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("更新文章版本失败: %w", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	article.Version++
+	article.UpdatedAt = now
+	return nil
+}
+
+// purgeArticles 永久删除文章，包括回收站中的文章，以及评论、标签和文件关联、历史slug、旧地址和导入记录，需在事务中调用
+func purgeArticles(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var commentIDs []uint
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("article_id IN ?", ids).Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+	if err := purgeComments(tx, commentIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Exec("DELETE FROM article_tags WHERE article_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM article_media WHERE article_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("article_id IN ?", ids).Delete(&models.ArticleSlug{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("article_id IN ?", ids).Delete(&models.ArticleRedirect{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("kind = ? AND local_id IN ?", models.ImportKindArticle, ids).
+		Delete(&models.ImportedItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Article{}).Error
+}
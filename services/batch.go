@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// 批量操作文章的类型
+const (
+	BatchPublish      = "publish"
+	BatchUnpublish    = "unpublish"
+	BatchDelete       = "delete"
+	BatchAddTags      = "add_tags"
+	BatchRemoveTags   = "remove_tags"
+	BatchSetTags      = "set_tags"
+	BatchChangeAuthor = "change_author"
+)
+
+// BatchInput 批量操作文章的参数
+type BatchInput struct {
+	Action   string
+	IDs      []uint
+	Tags     []string // add_tags、remove_tags、set_tags 的标签名
+	AuthorID uint     // change_author 的新作者
+	Atomic   bool     // 为true时在一个事务中执行，任一文章失败则全部不生效
+}
+
+// BatchItemResult 单篇文章的操作结果，Err为nil且Skipped为false表示成功；
+// Skipped表示原子执行时因其他文章失败而未生效
+type BatchItemResult struct {
+	ID      uint
+	Err     error
+	Skipped bool
+}
+
+// BatchArticles 批量操作文章，每篇文章按单独修改时的规则检查权限：change_author 只有管理员可以执行，
+// 其他操作只能操作自己的文章；非原子执行时每篇文章在单独的事务中执行，互不影响
+func BatchArticles(viewer Viewer, input BatchInput) ([]BatchItemResult, error) {
+	db := database.GetDB()
+
+	if (input.Action == BatchAddTags || input.Action == BatchRemoveTags) && len(input.Tags) == 0 {
+		return nil, utils.ErrInvalidParams.Wrap(fmt.Errorf("%s 需要提供标签", input.Action))
+	}
+
+	switch input.Action {
+	case BatchPublish, BatchUnpublish, BatchDelete, BatchRemoveTags:
+	case BatchAddTags, BatchSetTags:
+		// 标签在事务中创建，这里先检查标签名，避免每篇文章分别失败
+		for _, name := range input.Tags {
+			if utils.Slugify(name) == "" {
+				return nil, ErrInvalidTag
+			}
+		}
+	case BatchChangeAuthor:
+		if err := checkChangeAuthor(viewer); err != nil {
+			return nil, err
+		}
+		if _, err := GetUserByID(input.AuthorID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, utils.ErrInvalidParams.Wrap(fmt.Errorf("未知的批量操作: %s", input.Action))
+	}
+
+	// 去掉重复的ID，保持原有顺序
+	ids := make([]uint, 0, len(input.IDs))
+	seen := map[uint]bool{}
+	for _, id := range input.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	results := make([]BatchItemResult, len(ids))
+	for i, id := range ids {
+		results[i].ID = id
+	}
+
+	if !input.Atomic {
+		for i := range results {
+			results[i].Err = db.Transaction(func(tx *gorm.DB) error {
+				tags, err := batchTags(tx, input)
+				if err != nil {
+					return err
+				}
+				return batchArticle(tx, results[i].ID, viewer, input, tags)
+			})
+		}
+		invalidateArticles(ids...)
+		return results, nil
+	}
+
+	failed := -1
+	var tagsErr error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tags, err := batchTags(tx, input)
+		if err != nil {
+			tagsErr = err
+			return err
+		}
+		for i := range results {
+			if err := batchArticle(tx, results[i].ID, viewer, input, tags); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if tagsErr != nil {
+			return nil, tagsErr
+		}
+		if failed < 0 {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("批量操作文章失败: %w", err))
+		}
+		for i := range results {
+			results[i].Skipped = i != failed
+		}
+		results[failed].Err = err
+		return results, nil
+	}
+
+	invalidateArticles(ids...)
+	return results, nil
+}
+
+// batchTags 查询修改标签的操作使用的标签，add_tags 和 set_tags 会创建不存在的标签，需在事务中调用，
+// 事务回滚时新建的标签一并撤销
+func batchTags(tx *gorm.DB, input BatchInput) ([]models.Tag, error) {
+	var tags []models.Tag
+	switch input.Action {
+	case BatchAddTags, BatchSetTags:
+		return resolveTags(tx, input.Tags)
+	case BatchRemoveTags:
+		slugs := make([]string, 0, len(input.Tags))
+		for _, name := range input.Tags {
+			slugs = append(slugs, utils.Slugify(name))
+		}
+		if err := tx.Where("slug IN ?", slugs).Find(&tags).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询标签失败: %w", err))
+		}
+	}
+	return tags, nil
+}
+
+// batchArticle 对单篇文章执行批量操作，需在事务中调用
+func batchArticle(tx *gorm.DB, articleID uint, viewer Viewer, input BatchInput, tags []models.Tag) error {
+	var article models.Article
+	if err := tx.First(&article, articleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrArticleNotFound
+		}
+		return utils.ErrInternal.Wrap(fmt.Errorf("查询文章失败: %w", err))
+	}
+
+	// 检查权限，与单独修改文章的规则相同；更换作者的权限已在执行前检查
+	if input.Action != BatchChangeAuthor {
+		if err := checkArticleOwner(&article, viewer.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := bumpVersion(tx, &article); err != nil {
+		return err
+	}
+
+	var err error
+	switch input.Action {
+	case BatchPublish:
+		err = tx.Model(&article).Update("status", models.ArticleStatusPublished).Error
+	case BatchUnpublish:
+		err = tx.Model(&article).Update("status", models.ArticleStatusDraft).Error
+	case BatchDelete:
+		err = tx.Delete(&article).Error
+	case BatchAddTags:
+		if len(tags) > 0 {
+			err = tx.Model(&article).Association("Tags").Append(tags)
+		}
+	case BatchRemoveTags:
+		if len(tags) > 0 {
+			err = tx.Model(&article).Association("Tags").Delete(tags)
+		}
+	case BatchSetTags:
+		err = tx.Model(&article).Association("Tags").Replace(tags)
+	case BatchChangeAuthor:
+		if err = tx.Model(&article).Update("user_id", input.AuthorID).Error; err == nil {
+			// 引用的文件只记录作者自己的文件，更换作者后重新计算
+			article.UserID = input.AuthorID
+			err = syncArticleMedia(tx, &article)
+		}
+	}
+	if err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("更新文章失败: %w", err))
+	}
+	return nil
+}
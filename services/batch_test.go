@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+func TestBatchArticlesPerItem(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+	own := createArticle(t, alice.ID, "own")
+	other := createArticle(t, bob.ID, "other")
+
+	results, err := BatchArticles(Viewer{ID: alice.ID}, BatchInput{
+		Action: BatchAddTags,
+		IDs:    []uint{own.ID, other.ID, 9999, own.ID},
+		Tags:   []string{"go"},
+	})
+	if err != nil {
+		t.Fatalf("BatchArticles 返回错误: %v", err)
+	}
+
+	// 重复的ID只执行一次，每篇文章的结果互不影响
+	if len(results) != 3 {
+		t.Fatalf("结果数 %d，期望 3", len(results))
+	}
+	if results[0].Err != nil || results[0].Skipped {
+		t.Errorf("自己的文章应成功: %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, ErrArticleForbidden) {
+		t.Errorf("他人的文章错误 %v，期望 ErrArticleForbidden", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, ErrArticleNotFound) {
+		t.Errorf("不存在的文章错误 %v，期望 ErrArticleNotFound", results[2].Err)
+	}
+
+	if got := tagSlugs(loadArticle(t, own.ID)); !reflect.DeepEqual(got, []string{"go"}) {
+		t.Errorf("自己的文章标签 %v，期望 [go]", got)
+	}
+	if got := tagSlugs(loadArticle(t, other.ID)); len(got) != 0 {
+		t.Errorf("他人的文章标签 %v，期望不变", got)
+	}
+}
+
+func TestBatchArticlesAtomic(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+	first := createArticle(t, alice.ID, "first")
+	other := createArticle(t, bob.ID, "other")
+	last := createArticle(t, alice.ID, "last")
+
+	results, err := BatchArticles(Viewer{ID: alice.ID}, BatchInput{
+		Action: BatchAddTags,
+		IDs:    []uint{first.ID, other.ID, last.ID},
+		Tags:   []string{"new-tag"},
+		Atomic: true,
+	})
+	if err != nil {
+		t.Fatalf("BatchArticles 返回错误: %v", err)
+	}
+
+	// 失败的文章返回错误，其他文章标记为未生效
+	want := []struct {
+		skipped bool
+		err     error
+	}{{true, nil}, {false, ErrArticleForbidden}, {true, nil}}
+	for i, w := range want {
+		if results[i].Skipped != w.skipped || !errors.Is(results[i].Err, w.err) {
+			t.Errorf("第%d篇结果 %+v，期望 skipped=%t err=%v", i+1, results[i], w.skipped, w.err)
+		}
+	}
+
+	// 已执行的修改和事务中创建的标签一并回滚
+	for _, id := range []uint{first.ID, last.ID} {
+		article := loadArticle(t, id)
+		if len(article.Tags) != 0 || article.Version != first.Version {
+			t.Errorf("文章 %d 标签 %v 版本 %d，期望未修改", id, tagSlugs(article), article.Version)
+		}
+	}
+	var count int64
+	database.GetDB().Model(&models.Tag{}).Where("slug = ?", "new-tag").Count(&count)
+	if count != 0 {
+		t.Errorf("回滚后仍创建了标签")
+	}
+}
+
+func TestBatchArticlesChangeAuthor(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+	admin := createUser(t, "admin")
+	article := createArticle(t, bob.ID, "bob's")
+
+	tests := []struct {
+		name     string
+		viewer   Viewer
+		authorID uint
+		wantErr  error
+	}{
+		{"普通用户不能更换作者", Viewer{ID: bob.ID}, alice.ID, utils.ErrForbidden},
+		{"新作者不存在", Viewer{ID: admin.ID, Admin: true}, 9999, ErrUserNotFound},
+		{"管理员可以更换他人文章的作者", Viewer{ID: admin.ID, Admin: true}, alice.ID, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := BatchArticles(tt.viewer, BatchInput{Action: BatchChangeAuthor, IDs: []uint{article.ID}, AuthorID: tt.authorID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("错误 %v，期望 %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if results[0].Err != nil {
+				t.Fatalf("更换作者失败: %v", results[0].Err)
+			}
+			if got := loadArticle(t, article.ID).UserID; got != tt.authorID {
+				t.Errorf("作者 %d，期望 %d", got, tt.authorID)
+			}
+		})
+	}
+}
+
+func TestBatchArticlesActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    BatchInput
+		tags     []string
+		wantTags []string
+		check    func(t *testing.T, article models.Article)
+	}{
+		{
+			name:     "添加标签",
+			input:    BatchInput{Action: BatchAddTags, Tags: []string{"b"}},
+			tags:     []string{"a"},
+			wantTags: []string{"a", "b"},
+		},
+		{
+			name:     "删除标签",
+			input:    BatchInput{Action: BatchRemoveTags, Tags: []string{"a", "missing"}},
+			tags:     []string{"a", "b"},
+			wantTags: []string{"b"},
+		},
+		{
+			name:     "设置标签",
+			input:    BatchInput{Action: BatchSetTags, Tags: []string{"c"}},
+			tags:     []string{"a", "b"},
+			wantTags: []string{"c"},
+		},
+		{
+			name:  "取消发布",
+			input: BatchInput{Action: BatchUnpublish},
+			check: func(t *testing.T, article models.Article) {
+				if article.Status != models.ArticleStatusDraft {
+					t.Errorf("状态 %s，期望 draft", article.Status)
+				}
+			},
+		},
+		{
+			name:  "删除",
+			input: BatchInput{Action: BatchDelete},
+			check: func(t *testing.T, article models.Article) {
+				if !article.DeletedAt.Valid {
+					t.Errorf("文章未移入回收站")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupDB(t)
+			alice := createUser(t, "alice")
+			article := createArticle(t, alice.ID, "article", tt.tags...)
+			before := loadArticle(t, article.ID)
+			time.Sleep(10 * time.Millisecond)
+
+			tt.input.IDs = []uint{article.ID}
+			results, err := BatchArticles(Viewer{ID: alice.ID}, tt.input)
+			if err != nil || results[0].Err != nil {
+				t.Fatalf("BatchArticles 失败: %v %v", err, results)
+			}
+
+			after := loadArticle(t, article.ID)
+			if tt.wantTags != nil && !reflect.DeepEqual(tagSlugs(after), tt.wantTags) {
+				t.Errorf("标签 %v，期望 %v", tagSlugs(after), tt.wantTags)
+			}
+			if tt.check != nil {
+				tt.check(t, after)
+			}
+			// 每种操作都增加版本号并更新修改时间，使条件请求和增量导出感知到变化
+			if after.Version != before.Version+1 {
+				t.Errorf("版本 %d，期望 %d", after.Version, before.Version+1)
+			}
+			if !after.UpdatedAt.After(before.UpdatedAt) {
+				t.Errorf("修改时间 %v 未晚于 %v", after.UpdatedAt, before.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestBatchArticlesInvalidInput(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	article := createArticle(t, alice.ID, "article")
+
+	tests := []struct {
+		name    string
+		input   BatchInput
+		wantErr error
+	}{
+		{"未知操作", BatchInput{Action: "archive"}, utils.ErrInvalidParams},
+		{"添加标签未提供标签", BatchInput{Action: BatchAddTags}, utils.ErrInvalidParams},
+		{"无效的标签名", BatchInput{Action: BatchSetTags, Tags: []string{"!!!"}}, ErrInvalidTag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.IDs = []uint{article.ID}
+			if _, err := BatchArticles(Viewer{ID: alice.ID}, tt.input); !errors.Is(err, tt.wantErr) {
+				t.Errorf("错误 %v，期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/dingdinglz/test-blog/cache"
+	"github.com/dingdinglz/test-blog/models"
+)
+
+// 文章缓存的键：单篇文章为 article:<id> 和 article:slug:<slug>，文章列表以 articles: 开头
+const (
+	articleKeyPrefix     = "article:"
+	articleSlugKeyPrefix = "article:slug:"
+	articleListKeyPrefix = "articles:"
+)
+
+// articlePage 缓存的分页结果
+type articlePage struct {
+	articles []models.Article
+	total    int64
+}
+
+// articleKey 单篇文章的缓存键
+func articleKey(articleID uint) string {
+	return fmt.Sprintf("%s%d", articleKeyPrefix, articleID)
+}
+
+// key 加载选项的缓存键，加载内容不同的列表分别缓存
+func (l ArticleLoad) key() string {
+	return fmt.Sprintf("%t-%t-%t", l.Author, l.Tags, l.Content)
+}
+
+// cachedArticle 从缓存获取单篇文章，未命中时调用load加载；返回副本，调用方修改字段不影响缓存
+func cachedArticle(key string, load func() (*models.Article, error)) (*models.Article, error) {
+	value, err := cache.Load(key, func() (interface{}, error) {
+		return load()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	article := copyArticle(*value.(*models.Article))
+	return &article, nil
+}
+
+// cachedArticles 从缓存获取文章列表，未命中时调用load加载；返回副本，调用方修改字段不影响缓存
+func cachedArticles(key string, load func() ([]models.Article, error)) ([]models.Article, error) {
+	value, err := cache.Load(key, func() (interface{}, error) {
+		return load()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyArticles(value.([]models.Article)), nil
+}
+
+// copyArticle 复制缓存中的文章，标签和作者的切片、指针字段一并复制
+func copyArticle(article models.Article) models.Article {
+	article.Tags = append([]models.Tag(nil), article.Tags...)
+	article.User.SocialLinks = append([]models.SocialLink(nil), article.User.SocialLinks...)
+	article.User.Articles = append([]models.Article(nil), article.User.Articles...)
+	if article.User.AvatarMediaID != nil {
+		id := *article.User.AvatarMediaID
+		article.User.AvatarMediaID = &id
+	}
+	if article.User.DeleteAt != nil {
+		deleteAt := *article.User.DeleteAt
+		article.User.DeleteAt = &deleteAt
+	}
+	return article
+}
+
+// copyArticles 复制缓存中的文章列表，每篇文章按 copyArticle 复制
+func copyArticles(articles []models.Article) []models.Article {
+	result := make([]models.Article, len(articles))
+	for i := range articles {
+		result[i] = copyArticle(articles[i])
+	}
+	return result
+}
+
+// invalidateArticles 文章修改后清除缓存：删除指定文章和全部slug、列表缓存；
+// 未指定文章时（如作者资料修改）清除全部文章缓存
+func invalidateArticles(articleIDs ...uint) {
+	if len(articleIDs) == 0 {
+		cache.DeletePrefix(articleKeyPrefix)
+		cache.DeletePrefix(articleListKeyPrefix)
+		return
+	}
+
+	keys := make([]string, 0, len(articleIDs))
+	for _, id := range articleIDs {
+		keys = append(keys, articleKey(id))
+	}
+	cache.Delete(keys...)
+	cache.DeletePrefix(articleSlugKeyPrefix)
+	cache.DeletePrefix(articleListKeyPrefix)
+}
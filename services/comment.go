@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// GetArticleComments 获取文章已审核的评论，按时间正序
+func GetArticleComments(articleID uint) ([]models.Comment, error) {
+	db := database.GetDB()
+
+	var comments []models.Comment
+	if err := db.Where("article_id = ? AND status = ?", articleID, models.CommentStatusApproved).
+		Order("created_at asc, id asc").Find(&comments).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取评论列表失败: %w", err))
+	}
+
+	return comments, nil
+}
+
+// purgeComments 永久删除评论及其导入记录，回复这些评论的评论改为顶层评论，需在事务中调用
+func purgeComments(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("parent_id IN ?", ids).
+		UpdateColumn("parent_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("kind = ? AND local_id IN ?", models.ImportKindComment, ids).
+		Delete(&models.ImportedItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Comment{}).Error
+}
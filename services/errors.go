@@ -0,0 +1,42 @@
+package services
+
+import (
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+// 用户相关错误
+var (
+	ErrUserNotFound  = utils.NewError(utils.CodeUserNotFound, "用户不存在")
+	ErrUsernameTaken = utils.NewError(utils.CodeUsernameTaken, "用户名已存在")
+	ErrEmailTaken    = utils.NewError(utils.CodeEmailTaken, "邮箱已被注册")
+	ErrInvalidURL    = utils.NewError(utils.CodeInvalidURL, "网址必须是http(s)地址")
+	ErrInvalidAvatar = utils.NewError(utils.CodeInvalidAvatar, "头像必须是图片地址或自己上传的图片")
+	ErrWrongPassword = utils.NewError(utils.CodeWrongPassword, "密码错误")
+)
+
+// 文章相关错误
+var (
+	ErrArticleNotFound  = utils.NewError(utils.CodeArticleNotFound, "文章不存在")
+	ErrArticleForbidden = utils.NewError(utils.CodeArticleForbidden, "无权操作此文章")
+	ErrInvalidSlug      = utils.NewError(utils.CodeInvalidSlug, "slug只能包含小写字母、数字和连字符")
+	ErrSlugTaken        = utils.NewError(utils.CodeSlugTaken, "slug已被使用")
+	ErrInvalidCover     = utils.NewError(utils.CodeInvalidCover, "封面图片必须是http(s)地址或站内路径")
+	ErrNotInTrash       = utils.NewError(utils.CodeNotInTrash, "文章不在回收站中")
+	ErrVersionConflict  = utils.NewError(utils.CodeVersionConflict, "文章已被修改，请基于最新版本重新提交")
+)
+
+// 标签相关错误
+var (
+	ErrTagNotFound = utils.NewError(utils.CodeTagNotFound, "标签不存在")
+	ErrInvalidTag  = utils.NewError(utils.CodeInvalidTag, "标签名无效")
+)
+
+// 文件相关错误
+var (
+	ErrMediaNotFound       = utils.NewError(utils.CodeMediaNotFound, "文件不存在")
+	ErrMediaForbidden      = utils.NewError(utils.CodeMediaForbidden, "无权操作此文件")
+	ErrMediaInUse          = utils.NewError(utils.CodeMediaInUse, "文件正在被文章或头像使用")
+	ErrFileTooLarge        = utils.NewError(utils.CodeFileTooLarge, "文件过大")
+	ErrUnsupportedFileType = utils.NewError(utils.CodeUnsupportedFileType, "不支持的文件类型")
+	ErrImageTooLarge       = utils.NewError(utils.CodeImageTooLarge, "图片尺寸过大")
+)
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gorilla/feeds"
+)
+
+// BuildFeed 构建订阅源，username为空时为全站订阅源，否则为该作者的订阅源
+func BuildFeed(username string) (*feeds.Feed, error) {
+	site := config.AppConfig.Site
+	cfg := config.AppConfig.Feed
+
+	feed := &feeds.Feed{
+		Title:       site.Title,
+		Link:        &feeds.Link{Href: utils.AbsoluteURL("/")},
+		Description: site.Description,
+	}
+
+	var articles []models.Article
+	if username == "" {
+		var err error
+		if articles, err = GetLatestArticles(cfg.Limit); err != nil {
+			return nil, err
+		}
+	} else {
+		user, err := GetUserByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if articles, err = GetLatestUserArticles(user.ID, cfg.Limit); err != nil {
+			return nil, err
+		}
+
+		feed.Title = site.Title + " - " + user.Name()
+		feed.Author = &feeds.Author{Name: user.Name()}
+	}
+
+	for _, article := range articles {
+		item := &feeds.Item{
+			Title:       article.Title,
+			Link:        &feeds.Link{Href: utils.ArticleURL(article.Slug)},
+			Author:      &feeds.Author{Name: article.User.Name()},
+			Description: ArticleExcerpt(&article),
+			Id:          utils.AbsoluteURL(fmt.Sprintf("/api/articles/%d", article.ID)),
+			IsPermaLink: "false",
+			Created:     article.CreatedAt,
+			Updated:     article.UpdatedAt,
+		}
+		if cfg.Mode != "summary" {
+			item.Content = article.ContentHTML
+		}
+		feed.Add(item)
+
+		// 订阅源更新时间取最近修改的文章
+		if article.UpdatedAt.After(feed.Updated) {
+			feed.Updated = article.UpdatedAt
+		}
+	}
+
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Unix(0, 0)
+	}
+
+	return feed, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+)
+
+func TestBuildFeedAuthorLimit(t *testing.T) {
+	setupDB(t)
+	previous := config.AppConfig.Feed.Limit
+	config.AppConfig.Feed.Limit = 2
+	t.Cleanup(func() { config.AppConfig.Feed.Limit = previous })
+
+	db := database.GetDB()
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+
+	// 按创建时间从旧到新，草稿和其他作者的文章不计入
+	base := time.Now().Add(-time.Hour)
+	titles := []string{"一", "二", "草稿", "三", "bob的文章"}
+	for i, title := range titles {
+		userID := alice.ID
+		if title == "bob的文章" {
+			userID = bob.ID
+		}
+		article := createArticle(t, userID, title)
+		updates := map[string]interface{}{"created_at": base.Add(time.Duration(i) * time.Minute)}
+		if title == "草稿" {
+			updates["status"] = models.ArticleStatusDraft
+		}
+		db.Model(article).UpdateColumns(updates)
+	}
+	invalidateArticles()
+
+	feed, err := BuildFeed("alice")
+	if err != nil {
+		t.Fatalf("构建订阅源失败: %v", err)
+	}
+	got := []string{}
+	for _, item := range feed.Items {
+		got = append(got, item.Title)
+	}
+	if len(got) != 2 || got[0] != "三" || got[1] != "二" {
+		t.Errorf("订阅源文章 %v，期望 [三 二]", got)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyLockTimeout 请求处理中的记录超过该时间仍未完成时视为已中断，允许重新处理
+const idempotencyLockTimeout = 5 * time.Minute
+
+// BeginIdempotentRequest 登记幂等键，返回的记录Status为0时表示需要处理请求，否则为需要重放的响应；
+// 键已用于指纹不同的请求时返回 ErrIdempotencyReused，相同的请求正在处理中时返回 ErrIdempotencyBusy
+func BeginIdempotentRequest(userID uint, key, fingerprint string) (*models.IdempotencyKey, error) {
+	db := database.GetDB()
+
+	now := time.Now()
+	if err := db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("清理过期幂等键失败: %w", err))
+	}
+
+	record := &models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Duration(config.AppConfig.Idempotency.TTL) * time.Hour),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("保存幂等键失败: %w", result.Error))
+	}
+	if result.RowsAffected == 1 {
+		return record, nil
+	}
+
+	// 键已存在
+	var existing models.IdempotencyKey
+	if err := db.Where(map[string]interface{}{"user_id": userID, "key": key}).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrIdempotencyBusy
+		}
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询幂等键失败: %w", err))
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, utils.ErrIdempotencyReused
+	}
+	if existing.Status != 0 {
+		return &existing, nil
+	}
+	if now.Sub(existing.CreatedAt) < idempotencyLockTimeout {
+		return nil, utils.ErrIdempotencyBusy
+	}
+
+	// 接管已中断的请求，多个重试同时到达时只有一个能接管
+	result = db.Model(&models.IdempotencyKey{}).
+		Where("id = ? AND status = 0 AND created_at = ?", existing.ID, existing.CreatedAt).
+		Update("created_at", now)
+	if result.Error != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("更新幂等键失败: %w", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return nil, utils.ErrIdempotencyBusy
+	}
+	existing.CreatedAt = now
+	return &existing, nil
+}
+
+// CompleteIdempotentRequest 保存请求的响应，供使用相同幂等键的重试重放
+func CompleteIdempotentRequest(record *models.IdempotencyKey, status int, header map[string]string, body []byte) error {
+	db := database.GetDB()
+
+	record.Status = status
+	record.Header = header
+	record.Body = body
+	if err := db.Model(record).Select("status", "header", "body").Updates(record).Error; err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("保存幂等响应失败: %w", err))
+	}
+	return nil
+}
+
+// AbortIdempotentRequest 删除幂等键，请求失败且可以重试时使用，之后相同的键视为新请求
+func AbortIdempotentRequest(record *models.IdempotencyKey) error {
+	db := database.GetDB()
+
+	if err := db.Delete(record).Error; err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("删除幂等键失败: %w", err))
+	}
+	return nil
+}
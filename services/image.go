@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/buckket/go-blurhash"
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/storage"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/disintegration/imaging"
+	"gorm.io/gorm"
+
+	_ "golang.org/x/image/webp" // 解码WebP
+)
+
+// processableTypes 会生成缩放版本的图片类型，GIF可能是动图，保持原样
+var processableTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/webp": true}
+
+// imageRescanInterval 队列满时重新查询待处理文件的间隔
+const imageRescanInterval = time.Minute
+
+var (
+	// imageQueue 待处理的文件ID，由 StartImageWorkers 创建
+	imageQueue chan uint
+	// queuedMedia 已在队列中或正在处理的文件ID，避免重复加入队列
+	queuedMedia sync.Map
+	// imageQueueFull 有文件因队列满未能加入队列，需要重新查询
+	imageQueueFull atomic.Bool
+)
+
+// StartImageWorkers 启动后台图片处理协程，并把上次退出时还未处理的文件重新加入队列
+func StartImageWorkers() {
+	workers := config.AppConfig.Image.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	imageQueue = make(chan uint, 256)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range imageQueue {
+				if err := ProcessMedia(id); err != nil {
+					log.Printf("处理文件 %d 失败: %v\n", id, err)
+				}
+				queuedMedia.Delete(id)
+			}
+		}()
+	}
+
+	rescanPendingMedia()
+	go func() {
+		for range time.Tick(imageRescanInterval) {
+			if imageQueueFull.Swap(false) {
+				rescanPendingMedia()
+			}
+		}
+	}()
+}
+
+// rescanPendingMedia 把pending状态的文件加入处理队列
+func rescanPendingMedia() {
+	var ids []uint
+	if err := database.GetDB().Model(&models.Media{}).Where("status = ?", models.MediaStatusPending).
+		Order("id asc").Pluck("id", &ids).Error; err != nil {
+		log.Printf("查询待处理文件失败: %v\n", err)
+		return
+	}
+	for _, id := range ids {
+		enqueueMedia(id)
+	}
+}
+
+// enqueueMedia 把文件加入处理队列，未启动处理协程时（如命令行导入）保持pending状态，由下次启动服务或 process-media 命令处理
+func enqueueMedia(id uint) {
+	if imageQueue == nil {
+		return
+	}
+	if _, queued := queuedMedia.LoadOrStore(id, true); queued {
+		return
+	}
+	// 队列满时不阻塞上传请求，文件保持pending状态，稍后重新查询时再加入队列
+	select {
+	case imageQueue <- id:
+	default:
+		queuedMedia.Delete(id)
+		imageQueueFull.Store(true)
+	}
+}
+
+// checkImageSize 解码前检查图片尺寸，宽高之积超过 image.max_pixels 时返回 ErrImageTooLarge；
+// 无法读取尺寸时返回nil，由之后的解码报告错误
+func checkImageSize(data []byte) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > config.AppConfig.Image.MaxPixels {
+		return ErrImageTooLarge.Wrap(fmt.Errorf("图片尺寸: %dx%d", cfg.Width, cfg.Height))
+	}
+	return nil
+}
+
+// sanitizeImage 移除上传图片中的EXIF等元数据，带方向信息的照片先按方向旋转再重新编码
+func sanitizeImage(mimeType string, data []byte) ([]byte, error) {
+	stripped, orientation, err := utils.StripMetadata(mimeType, data)
+	if err != nil {
+		return nil, err
+	}
+	if orientation <= 1 {
+		return stripped, nil
+	}
+
+	if err := checkImageSize(data); err != nil {
+		return nil, err
+	}
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(95)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ProcessMedia 读取图片尺寸，生成blurhash、主色调和各尺寸版本，已有的版本会重新生成；非图片文件直接标记为完成
+func ProcessMedia(mediaID uint) error {
+	db := database.GetDB()
+	store := storage.GetStorage()
+
+	var media models.Media
+	if err := db.Preload("Variants").First(&media, mediaID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMediaNotFound
+		}
+		return utils.ErrInternal.Wrap(fmt.Errorf("查询文件失败: %w", err))
+	}
+
+	if !strings.HasPrefix(media.MimeType, "image/") {
+		return db.Model(&media).UpdateColumn("status", models.MediaStatusReady).Error
+	}
+
+	img, err := loadImage(media.Key)
+	if err != nil {
+		db.Model(&media).UpdateColumn("status", models.MediaStatusFailed)
+		return fmt.Errorf("解码图片失败: %w", err)
+	}
+
+	bounds := img.Bounds()
+	media.Width, media.Height = bounds.Dx(), bounds.Dy()
+
+	// 占位信息在缩小后的图片上计算
+	small := imaging.Fit(img, 64, 64, imaging.Box)
+	media.Blurhash, err = blurhash.Encode(4, 3, small)
+	if err != nil {
+		media.Blurhash = ""
+	}
+	c := color.NRGBAModel.Convert(imaging.Resize(small, 1, 1, imaging.Box).At(0, 0)).(color.NRGBA)
+	media.DominantColor = fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+
+	variants := []models.MediaVariant{}
+	if processableTypes[media.MimeType] {
+		variants, err = generateVariants(&media, img)
+		if err != nil {
+			deleteVariantFiles(variants)
+			db.Model(&media).UpdateColumn("status", models.MediaStatusFailed)
+			return err
+		}
+	}
+
+	old := media.Variants
+	media.Status = models.MediaStatusReady
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("media_id = ?", media.ID).Delete(&models.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		// 处理期间文件可能已被删除
+		result := tx.Model(&media).Select("width", "height", "blurhash", "dominant_color", "status").Updates(&media)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMediaNotFound
+		}
+		if len(variants) > 0 {
+			return tx.Create(&variants).Error
+		}
+		return nil
+	})
+	if err != nil {
+		deleteVariantFiles(variants)
+		if errors.Is(err, ErrMediaNotFound) {
+			return err
+		}
+		return utils.ErrInternal.Wrap(fmt.Errorf("保存图片信息失败: %w", err))
+	}
+
+	// 新版本已保存，删除key不再使用的旧版本文件
+	for _, variant := range old {
+		if !containsVariantKey(variants, variant.Key) {
+			store.Delete(context.Background(), variant.Key)
+		}
+	}
+	return nil
+}
+
+// loadImage 从存储中读取并解码图片
+func loadImage(key string) (image.Image, error) {
+	r, err := storage.GetStorage().Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImageSize(data); err != nil {
+		return nil, err
+	}
+	return imaging.Decode(bytes.NewReader(data))
+}
+
+// generateVariants 按配置的尺寸生成缩放版本并写入存储，不会放大比目标尺寸小的图片
+func generateVariants(media *models.Media, img image.Image) ([]models.MediaVariant, error) {
+	cfg := config.AppConfig.Image
+	bounds := img.Bounds()
+
+	// 有透明通道的图片保存为PNG，其余保存为JPEG
+	format, ext := "jpeg", ".jpg"
+	if !imaging.Clone(img).Opaque() {
+		format, ext = "png", ".png"
+	}
+	base := strings.TrimSuffix(media.Key, mediaExtension(media.Key))
+
+	variants := []models.MediaVariant{}
+	for _, v := range cfg.Variants {
+		var resized *image.NRGBA
+		crop := v.Height > 0
+		switch {
+		case crop:
+			if bounds.Dx() < v.Width || bounds.Dy() < v.Height {
+				continue
+			}
+			resized = imaging.Fill(img, v.Width, v.Height, imaging.Center, imaging.Lanczos)
+		case v.Width > 0 && bounds.Dx() > v.Width:
+			resized = imaging.Resize(img, v.Width, 0, imaging.Lanczos)
+		default:
+			continue
+		}
+
+		var buf bytes.Buffer
+		var err error
+		if format == "png" {
+			err = imaging.Encode(&buf, resized, imaging.PNG)
+		} else {
+			err = imaging.Encode(&buf, resized, imaging.JPEG, imaging.JPEGQuality(cfg.Quality))
+		}
+		if err != nil {
+			return variants, fmt.Errorf("编码图片失败: %w", err)
+		}
+		variant, err := putVariant(media.ID, v.Name, format, base+"-"+v.Name+ext, crop, resized, &buf)
+		if err != nil {
+			return variants, err
+		}
+		variants = append(variants, *variant)
+
+		if cfg.WebP {
+			buf.Reset()
+			if err := nativewebp.Encode(&buf, resized, nil); err != nil {
+				return variants, fmt.Errorf("编码WebP失败: %w", err)
+			}
+			variant, err := putVariant(media.ID, v.Name, "webp", base+"-"+v.Name+".webp", crop, resized, &buf)
+			if err != nil {
+				return variants, err
+			}
+			variants = append(variants, *variant)
+		}
+	}
+	return variants, nil
+}
+
+// putVariant 把编码后的缩放版本写入存储
+func putVariant(mediaID uint, name, format, key string, crop bool, img image.Image, buf *bytes.Buffer) (*models.MediaVariant, error) {
+	size := int64(buf.Len())
+	if err := storage.GetStorage().Put(context.Background(), key, buf, size, "image/"+format); err != nil {
+		return nil, fmt.Errorf("保存图片 %s 失败: %w", key, err)
+	}
+	return &models.MediaVariant{
+		MediaID: mediaID,
+		Name:    name,
+		Format:  format,
+		Crop:    crop,
+		Key:     key,
+		Width:   img.Bounds().Dx(),
+		Height:  img.Bounds().Dy(),
+		Size:    size,
+	}, nil
+}
+
+// deleteVariantFiles 删除存储中的缩放版本文件
+func deleteVariantFiles(variants []models.MediaVariant) {
+	for _, variant := range variants {
+		if err := storage.GetStorage().Delete(context.Background(), variant.Key); err != nil {
+			log.Printf("删除文件 %s 失败: %v\n", variant.Key, err)
+		}
+	}
+}
+
+// containsVariantKey 判断缩放版本中是否有指定key
+func containsVariantKey(variants []models.MediaVariant, key string) bool {
+	for _, variant := range variants {
+		if variant.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaExtension 文件key的扩展名
+func mediaExtension(key string) string {
+	if i := strings.LastIndex(key, "."); i > strings.LastIndex(key, "/") {
+		return key[i:]
+	}
+	return ""
+}
+
+// ReprocessMedia 同步处理文件，all为false时只处理未完成的文件，返回处理的文件数
+func ReprocessMedia(all bool, progress func(media *models.Media, err error)) (int, error) {
+	db := database.GetDB()
+
+	query := db.Model(&models.Media{}).Order("id asc")
+	if !all {
+		query = query.Where("status <> ?", models.MediaStatusReady)
+	}
+	var media []models.Media
+	if err := query.Find(&media).Error; err != nil {
+		return 0, utils.ErrInternal.Wrap(fmt.Errorf("查询文件失败: %w", err))
+	}
+
+	for i := range media {
+		err := ProcessMedia(media[i].ID)
+		if progress != nil {
+			progress(&media[i], err)
+		}
+	}
+	return len(media), nil
+}
+
+// SrcSet 生成img标签srcset属性的值，只包含与原图比例相同的版本；webp为false时包含原图，为true时只包含WebP版本
+func SrcSet(media *models.Media, webp bool) string {
+	if media.Width == 0 {
+		return ""
+	}
+
+	store := storage.GetStorage()
+	parts := []string{}
+	for _, variant := range media.Variants {
+		if (variant.Format == "webp") == webp && !variant.Crop {
+			parts = append(parts, fmt.Sprintf("%s %dw", store.URL(variant.Key), variant.Width))
+		}
+	}
+	if len(parts) == 0 && webp {
+		return ""
+	}
+	if !webp || media.MimeType == "image/webp" {
+		parts = append(parts, fmt.Sprintf("%s %dw", store.URL(media.Key), media.Width))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// readAll 读取上传文件的全部内容
+func readAll(r io.Reader, size int64) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	_, err := buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
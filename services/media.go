@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/storage"
+	"github.com/dingdinglz/test-blog/utils"
+	"github.com/gabriel-vasile/mimetype"
+	"gorm.io/gorm"
+)
+
+// sniffSize 识别文件类型读取的字节数
+const sniffSize = 3072
+
+// mediaKeyPattern 文件key中不含扩展名的部分，用于从文章内容中找出引用的文件，引用缩放版本也算作引用原文件
+var mediaKeyPattern = regexp.MustCompile(`\d{4}/\d{2}/[0-9a-f]{32}`)
+
+// mediaKeyPrefixLen mediaKeyPattern匹配的长度
+const mediaKeyPrefixLen = 40
+
+// MaxUploadSize 单个文件的大小上限（字节）
+func MaxUploadSize() int64 {
+	return config.AppConfig.Upload.MaxSizeMB << 20
+}
+
+// UploadMedia 保存上传的文件，按文件内容而不是扩展名识别类型
+func UploadMedia(userID uint, filename string, r io.Reader, size int64) (*models.Media, error) {
+	db := database.GetDB()
+
+	if size > MaxUploadSize() {
+		return nil, ErrFileTooLarge
+	}
+
+	// 识别文件类型
+	header := make([]byte, sniffSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("读取上传文件失败: %w", err))
+	}
+	header = header[:n]
+
+	mtype := mimetype.Detect(header)
+	if !allowedType(mtype) {
+		return nil, ErrUnsupportedFileType.Wrap(fmt.Errorf("文件类型: %s", mtype.String()))
+	}
+
+	// 生成不可猜测的key，按年月分目录
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, utils.ErrInternal.Wrap(err)
+	}
+	key := time.Now().Format("2006/01/") + hex.EncodeToString(random) + mtype.Extension()
+
+	var body io.Reader = io.MultiReader(bytes.NewReader(header), r)
+
+	if processableTypes[mtype.String()] || mtype.Is("image/gif") {
+		data, err := readAll(body, size)
+		if err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("读取上传文件失败: %w", err))
+		}
+		// 拒绝声明了超大尺寸的图片，避免后台解码时耗尽内存
+		if err := checkImageSize(data); err != nil {
+			return nil, err
+		}
+		// 移除照片中的拍摄地点等元数据
+		if mtype.Is("image/jpeg") || mtype.Is("image/png") || mtype.Is("image/webp") {
+			if data, err = sanitizeImage(mtype.String(), data); err != nil {
+				return nil, ErrUnsupportedFileType.Wrap(fmt.Errorf("无法解析的图片: %w", err))
+			}
+		}
+		body, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	if err := storage.GetStorage().Put(context.Background(), key, body, size, mtype.String()); err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("保存文件失败: %w", err))
+	}
+
+	media := &models.Media{
+		UserID:   userID,
+		Key:      key,
+		Filename: filepath.Base(filename),
+		MimeType: mtype.String(),
+		Size:     size,
+		Status:   models.MediaStatusPending,
+	}
+	if err := db.Create(media).Error; err != nil {
+		storage.GetStorage().Delete(context.Background(), key)
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("保存文件记录失败: %w", err))
+	}
+
+	// 后台生成缩放版本
+	enqueueMedia(media.ID)
+
+	return media, nil
+}
+
+// allowedType 检查文件类型是否在允许上传的列表中
+func allowedType(mtype *mimetype.MIME) bool {
+	for _, allowed := range config.AppConfig.Upload.AllowedTypes {
+		if mtype.Is(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// MediaURL 文件的访问地址
+func MediaURL(key string) string {
+	return storage.GetStorage().URL(key)
+}
+
+// GetUserMedia 获取用户上传的文件，包括引用文件的文章ID
+func GetUserMedia(userID uint) ([]models.Media, error) {
+	db := database.GetDB()
+
+	var media []models.Media
+	if err := db.Preload("Articles", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped().Select("id") }).
+		Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("id asc") }).
+		Where("user_id = ?", userID).Order("created_at desc").Find(&media).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取文件列表失败: %w", err))
+	}
+
+	return media, nil
+}
+
+// DeleteMedia 删除文件，仍被文章引用或用作头像的文件不能删除
+func DeleteMedia(mediaID, userID uint) error {
+	db := database.GetDB()
+
+	var media models.Media
+	if err := db.First(&media, mediaID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMediaNotFound
+		}
+		return utils.ErrInternal.Wrap(fmt.Errorf("查询文件失败: %w", err))
+	}
+
+	// 检查权限
+	if media.UserID != userID {
+		return ErrMediaForbidden
+	}
+
+	var count int64
+	if err := db.Table("article_media").Where("media_id = ?", media.ID).Count(&count).Error; err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("查询文件引用失败: %w", err))
+	}
+	if count > 0 {
+		return ErrMediaInUse
+	}
+	if err := db.Model(&models.User{}).Where("avatar_media_id = ?", media.ID).Count(&count).Error; err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("查询文件引用失败: %w", err))
+	}
+	if count > 0 {
+		return ErrMediaInUse
+	}
+
+	return deleteMedia(db, &media)
+}
+
+// deleteMedia 删除文件记录和存储中的文件，包括缩放版本和文章引用记录
+func deleteMedia(db *gorm.DB, media *models.Media) error {
+	var variants []models.MediaVariant
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("media_id = ?", media.ID).Find(&variants).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM article_media WHERE media_id = ?", media.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("media_id = ?", media.ID).Delete(&models.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(media).Error
+	})
+	if err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("删除文件记录失败: %w", err))
+	}
+	deleteVariantFiles(variants)
+	if err := storage.GetStorage().Delete(context.Background(), media.Key); err != nil {
+		// 记录已删除，文件残留不影响使用
+		log.Printf("删除文件 %s 失败: %v\n", media.Key, err)
+	}
+	return nil
+}
+
+// syncArticleMedia 根据文章内容和封面更新文章引用的文件，需在事务中调用；
+// 只记录文章作者自己上传的文件，引用其他用户的文件不影响其删除
+func syncArticleMedia(tx *gorm.DB, article *models.Article) error {
+	keys := mediaKeyPattern.FindAllString(article.Content+"\n"+article.CoverImage, -1)
+
+	media := []models.Media{}
+	if len(keys) > 0 {
+		if err := tx.Where(fmt.Sprintf("substr(key, 1, %d) IN ?", mediaKeyPrefixLen), keys).
+			Where("user_id = ?", article.UserID).Find(&media).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(article).Association("Media").Replace(media)
+}
+
+// CleanupOrphanMedia 删除超过 upload.orphan_ttl 小时仍未被任何文章引用、也未用作头像的文件，dryRun为true时只返回待删除的文件
+func CleanupOrphanMedia(dryRun bool) ([]models.Media, error) {
+	db := database.GetDB()
+
+	cutoff := time.Now().Add(-time.Duration(config.AppConfig.Upload.OrphanTTL) * time.Hour)
+
+	var media []models.Media
+	if err := db.Where("created_at < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM article_media WHERE article_media.media_id = media.id)").
+		Where("NOT EXISTS (SELECT 1 FROM users WHERE users.avatar_media_id = media.id)").
+		Order("id asc").Find(&media).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询未引用文件失败: %w", err))
+	}
+
+	if dryRun {
+		return media, nil
+	}
+
+	for i := range media {
+		if err := deleteMedia(db, &media[i]); err != nil {
+			return media[:i], err
+		}
+	}
+	return media, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+)
+
+func TestMain(m *testing.M) {
+	if err := config.LoadConfig(); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// setupDB 为测试创建独立的数据库
+func setupDB(t *testing.T) {
+	t.Helper()
+	config.AppConfig.Database.Path = filepath.Join(t.TempDir(), "blog.db")
+	if err := database.Init(); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := database.GetDB().DB(); err == nil {
+			db.Close()
+		}
+	})
+}
+
+// createUser 创建测试用户，密码为 secret123
+func createUser(t *testing.T, username string) *models.User {
+	t.Helper()
+	user, err := Register(username, "secret123", username+"@example.com")
+	if err != nil {
+		t.Fatalf("创建用户 %s 失败: %v", username, err)
+	}
+	return user
+}
+
+// createArticle 创建测试文章
+func createArticle(t *testing.T, userID uint, title string, tags ...string) *models.Article {
+	t.Helper()
+	article, err := CreateArticle(ArticleInput{Title: title, Content: "内容", Tags: tags}, userID)
+	if err != nil {
+		t.Fatalf("创建文章 %s 失败: %v", title, err)
+	}
+	return article
+}
+
+// loadArticle 从数据库读取文章，包含标签和回收站中的文章
+func loadArticle(t *testing.T, articleID uint) models.Article {
+	t.Helper()
+	var article models.Article
+	if err := database.GetDB().Unscoped().Preload("Tags").First(&article, articleID).Error; err != nil {
+		t.Fatalf("读取文章 %d 失败: %v", articleID, err)
+	}
+	return article
+}
+
+// tagSlugs 文章标签的slug
+func tagSlugs(article models.Article) []string {
+	slugs := []string{}
+	for _, tag := range article.Tags {
+		slugs = append(slugs, tag.Slug)
+	}
+	return slugs
+}
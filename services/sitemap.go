@@ -0,0 +1,192 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// SitemapMaxURLs 单个站点地图文件的URL上限，超过时输出站点地图索引
+const SitemapMaxURLs = 50000
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapURL 站点地图条目
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// sitemapEntry 带修改时间的页面地址
+type sitemapEntry struct {
+	loc     string
+	lastMod time.Time
+}
+
+// sitemapEntries 收集已发布文章、作者和标签页面，作者和标签页面的修改时间取其下最近修改的文章
+func sitemapEntries() ([]sitemapEntry, error) {
+	db := database.GetDB()
+
+	var articles []models.Article
+	if err := db.Scopes(published).Select("id", "slug", "user_id", "updated_at").
+		Preload("User", func(tx *gorm.DB) *gorm.DB { return tx.Select("id", "username") }).
+		Preload("Tags").
+		Order("id asc").Find(&articles).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取站点地图文章失败: %w", err))
+	}
+
+	entries := make([]sitemapEntry, 0, len(articles))
+	authors := map[string]time.Time{}
+	tags := map[string]time.Time{}
+
+	for _, article := range articles {
+		entries = append(entries, sitemapEntry{loc: utils.ArticleURL(article.Slug), lastMod: article.UpdatedAt})
+
+		if article.UpdatedAt.After(authors[article.User.Username]) {
+			authors[article.User.Username] = article.UpdatedAt
+		}
+		for _, tag := range article.Tags {
+			if article.UpdatedAt.After(tags[tag.Slug]) {
+				tags[tag.Slug] = article.UpdatedAt
+			}
+		}
+	}
+
+	entries = append(entries, sortedEntries(authors, utils.AuthorURL)...)
+	entries = append(entries, sortedEntries(tags, utils.TagURL)...)
+	return entries, nil
+}
+
+// sortedEntries 按键排序生成条目，保证输出稳定
+func sortedEntries(lastMods map[string]time.Time, toURL func(string) string) []sitemapEntry {
+	keys := make([]string, 0, len(lastMods))
+	for key := range lastMods {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]sitemapEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, sitemapEntry{loc: toURL(key), lastMod: lastMods[key]})
+	}
+	return entries
+}
+
+// SitemapPages 获取站点地图分页数量，不超过上限时为1
+func SitemapPages() (int, error) {
+	entries, err := sitemapEntries()
+	if err != nil {
+		return 0, err
+	}
+	return pageCount(len(entries)), nil
+}
+
+func pageCount(total int) int {
+	if total <= SitemapMaxURLs {
+		return 1
+	}
+	return (total + SitemapMaxURLs - 1) / SitemapMaxURLs
+}
+
+// SitemapPageURL 站点地图分页的地址
+func SitemapPageURL(page int) string {
+	return utils.AbsoluteURL(fmt.Sprintf("/sitemap/%d.xml", page))
+}
+
+// BuildSitemap 生成站点地图，page为0时生成 /sitemap.xml（URL超过上限时为索引），否则生成对应分页
+func BuildSitemap(page int) ([]byte, time.Time, error) {
+	entries, err := sitemapEntries()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	pages := pageCount(len(entries))
+	if page < 0 || page > pages {
+		return nil, time.Time{}, utils.ErrNotFound
+	}
+
+	var doc interface{}
+	var lastMod time.Time
+
+	if page == 0 && pages > 1 {
+		index := sitemapIndex{XMLNS: sitemapXMLNS}
+		for i := 1; i <= pages; i++ {
+			pageLastMod := latest(entries[(i-1)*SitemapMaxURLs : min(i*SitemapMaxURLs, len(entries))])
+			index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: SitemapPageURL(i), LastMod: formatLastMod(pageLastMod)})
+			if pageLastMod.After(lastMod) {
+				lastMod = pageLastMod
+			}
+		}
+		doc = index
+	} else {
+		if page > 0 {
+			entries = entries[(page-1)*SitemapMaxURLs : min(page*SitemapMaxURLs, len(entries))]
+		}
+		set := sitemapURLSet{XMLNS: sitemapXMLNS, URLs: make([]sitemapURL, 0, len(entries))}
+		for _, entry := range entries {
+			set.URLs = append(set.URLs, sitemapURL{Loc: entry.loc, LastMod: formatLastMod(entry.lastMod)})
+		}
+		lastMod = latest(entries)
+		doc = set
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, time.Time{}, utils.ErrInternal.Wrap(fmt.Errorf("生成站点地图失败: %w", err))
+	}
+
+	return append([]byte(xml.Header), body...), lastMod, nil
+}
+
+func latest(entries []sitemapEntry) time.Time {
+	var t time.Time
+	for _, entry := range entries {
+		if entry.lastMod.After(t) {
+			t = entry.lastMod
+		}
+	}
+	return t
+}
+
+func formatLastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// BuildRobots 根据配置生成robots.txt
+func BuildRobots() string {
+	cfg := config.AppConfig.Robots
+
+	var sb strings.Builder
+	sb.WriteString("User-agent: *\n")
+	for _, path := range cfg.Allow {
+		sb.WriteString("Allow: " + path + "\n")
+	}
+	for _, path := range cfg.Disallow {
+		sb.WriteString("Disallow: " + path + "\n")
+	}
+	sb.WriteString("\nSitemap: " + utils.AbsoluteURL("/sitemap.xml") + "\n")
+	return sb.String()
+}
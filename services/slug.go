@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// slugTaken 检查slug是否已被其它文章使用（包括已删除文章和历史slug）
+func slugTaken(db *gorm.DB, slug string, articleID uint) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&models.Article{}).
+		Where("slug = ? AND id <> ?", slug, articleID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := db.Model(&models.ArticleSlug{}).
+		Where("slug = ? AND article_id <> ?", slug, articleID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// uniqueSlug 根据标题生成不冲突的slug，冲突时追加数字后缀
+func uniqueSlug(db *gorm.DB, title string, articleID uint) (string, error) {
+	base := utils.Slugify(title)
+	if base == "" {
+		base = "article"
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		taken, err := slugTaken(db, slug, articleID)
+		if err != nil {
+			return "", utils.ErrInternal.Wrap(fmt.Errorf("检查slug失败: %w", err))
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// checkSlug 校验用户指定的slug
+func checkSlug(db *gorm.DB, slug string, articleID uint) error {
+	if !utils.ValidSlug(slug) {
+		return ErrInvalidSlug
+	}
+
+	taken, err := slugTaken(db, slug, articleID)
+	if err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("检查slug失败: %w", err))
+	}
+	if taken {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+// changeSlug 修改文章slug并把旧slug记入历史，需在事务中调用
+func changeSlug(tx *gorm.DB, article *models.Article, slug string) error {
+	if article.Slug == slug {
+		return nil
+	}
+
+	// 改回曾经使用过的slug时，移除对应的历史记录
+	if err := tx.Unscoped().Where("article_id = ? AND slug = ?", article.ID, slug).
+		Delete(&models.ArticleSlug{}).Error; err != nil {
+		return err
+	}
+
+	if article.Slug != "" {
+		if err := tx.Create(&models.ArticleSlug{ArticleID: article.ID, Slug: article.Slug}).Error; err != nil {
+			return err
+		}
+	}
+
+	article.Slug = slug
+	return nil
+}
+
+// GetArticleBySlug 根据slug获取文章，历史slug同样可以查到，调用方可比较 article.Slug 判断是否需要重定向，草稿可见性同 GetArticleByID
+func GetArticleBySlug(slug string) (*models.Article, error) {
+	return cachedArticle("article:slug:"+slug, func() (*models.Article, error) {
+		db := database.GetDB()
+
+		var article models.Article
+		err := db.Preload("User").Preload("Tags").Where("slug = ?", slug).First(&article).Error
+		if err == nil {
+			ensureRendered(db, &article)
+			return &article, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询文章失败: %w", err))
+		}
+
+		// 查找历史slug
+		var history models.ArticleSlug
+		if err := db.Where("slug = ?", slug).First(&history).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrArticleNotFound
+			}
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询历史slug失败: %w", err))
+		}
+
+		return GetArticleByID(history.ArticleID)
+	})
+}
+
+// GetSlugRedirects 获取已发布文章的历史slug到当前slug的映射
+func GetSlugRedirects() (map[string]string, error) {
+	db := database.GetDB()
+
+	var rows []struct {
+		Old     string
+		Current string
+	}
+	if err := db.Model(&models.ArticleSlug{}).
+		Select("article_slugs.slug AS old, articles.slug AS current").
+		Joins("JOIN articles ON articles.id = article_slugs.article_id AND articles.deleted_at IS NULL AND articles.status = ?", models.ArticleStatusPublished).
+		Scan(&rows).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取历史slug失败: %w", err))
+	}
+
+	redirects := make(map[string]string, len(rows))
+	for _, row := range rows {
+		redirects[row.Old] = row.Current
+	}
+	return redirects, nil
+}
+
+// GetArticleRedirect 根据迁移前的旧地址查找已发布文章的当前slug
+func GetArticleRedirect(path string) (string, error) {
+	db := database.GetDB()
+
+	var slug string
+	err := db.Model(&models.ArticleRedirect{}).Select("articles.slug").
+		Joins("JOIN articles ON articles.id = article_redirects.article_id AND articles.deleted_at IS NULL AND articles.status = ?", models.ArticleStatusPublished).
+		Where("article_redirects.path = ?", path).Limit(1).Scan(&slug).Error
+	if err != nil {
+		return "", utils.ErrInternal.Wrap(fmt.Errorf("查询旧地址失败: %w", err))
+	}
+	if slug == "" {
+		return "", ErrArticleNotFound
+	}
+	return slug, nil
+}
+
+// GetArticleRedirects 获取所有迁移前旧地址到已发布文章当前slug的映射
+func GetArticleRedirects() (map[string]string, error) {
+	db := database.GetDB()
+
+	var rows []struct {
+		Path string
+		Slug string
+	}
+	if err := db.Model(&models.ArticleRedirect{}).
+		Select("article_redirects.path AS path, articles.slug AS slug").
+		Joins("JOIN articles ON articles.id = article_redirects.article_id AND articles.deleted_at IS NULL AND articles.status = ?", models.ArticleStatusPublished).
+		Scan(&rows).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取旧地址失败: %w", err))
+	}
+
+	redirects := make(map[string]string, len(rows))
+	for _, row := range rows {
+		redirects[row.Path] = row.Slug
+	}
+	return redirects, nil
+}
+
+// BackfillSlugs 为没有slug的旧文章生成slug
+func BackfillSlugs() error {
+	db := database.GetDB()
+
+	var articles []models.Article
+	if err := db.Unscoped().Where("slug IS NULL OR slug = ''").Find(&articles).Error; err != nil {
+		return err
+	}
+
+	for _, article := range articles {
+		slug, err := uniqueSlug(db, article.Title, article.ID)
+		if err != nil {
+			return err
+		}
+		if err := db.Unscoped().Model(&article).UpdateColumn("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(articles) > 0 {
+		log.Printf("已为 %d 篇文章生成slug\n", len(articles))
+	}
+	return nil
+}
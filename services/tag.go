@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// resolveTags 根据标签名查找或创建标签，slug相同的标签名视为同一标签
+func resolveTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		slug := utils.Slugify(name)
+		if slug == "" {
+			return nil, ErrInvalidTag
+		}
+		if seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		tag := models.Tag{Name: name, Slug: slug}
+		if err := tx.Where("slug = ?", slug).FirstOrCreate(&tag).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("保存标签失败: %w", err))
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// GetAllTags 获取所有标签
+func GetAllTags() ([]models.Tag, error) {
+	db := database.GetDB()
+
+	var tags []models.Tag
+	if err := db.Order("name asc").Find(&tags).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取标签列表失败: %w", err))
+	}
+
+	return tags, nil
+}
+
+// GetTagBySlug 根据slug获取标签
+func GetTagBySlug(slug string) (*models.Tag, error) {
+	db := database.GetDB()
+
+	var tag models.Tag
+	if err := db.Where("slug = ?", slug).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询标签失败: %w", err))
+	}
+
+	return &tag, nil
+}
+
+// GetTagArticles 获取指定标签下的已发布文章
+func GetTagArticles(tagID uint, load ArticleLoad) ([]models.Article, error) {
+	return cachedArticles(fmt.Sprintf("articles:tag:%d:%s", tagID, load.key()), func() ([]models.Article, error) {
+		db := database.GetDB()
+
+		var articles []models.Article
+		if err := db.Scopes(published, load.scope).
+			Joins("JOIN article_tags ON article_tags.article_id = articles.id").
+			Where("article_tags.tag_id = ?", tagID).
+			Order("created_at desc").Find(&articles).Error; err != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取标签文章列表失败: %w", err))
+		}
+
+		for i := range articles {
+			ensureRendered(db, &articles[i])
+		}
+
+		return articles, nil
+	})
+}
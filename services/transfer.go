@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// 导入结果
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+)
+
+// ImportDocument 待导入的文章，由命令行从Markdown文件解析得到
+type ImportDocument struct {
+	Title         string
+	Slug          string
+	Author        string // 作者用户名，为空时使用默认作者
+	Content       string
+	ContentFormat string
+	Tags          []string
+	Status        string
+	Excerpt       string
+	CoverImage    string
+	CreatedAt     time.Time // 为零值时使用当前时间
+	UpdatedAt     time.Time // 为零值时与CreatedAt相同
+}
+
+// ImportArticle 按slug导入文章，slug已存在（包括历史slug）时更新该文章，内容相同时不做修改，可重复执行
+func ImportArticle(doc ImportDocument, defaultAuthor string) (string, *models.Article, error) {
+	db := database.GetDB()
+
+	if !utils.ValidSlug(doc.Slug) {
+		return "", nil, ErrInvalidSlug
+	}
+
+	// 按用户名映射作者
+	username := doc.Author
+	if username == "" {
+		username = defaultAuthor
+	}
+	author, err := GetUserByUsername(username)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) && username != defaultAuthor && defaultAuthor != "" {
+			author, err = GetUserByUsername(defaultAuthor)
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("作者 %q 不存在: %w", username, err)
+		}
+	}
+
+	if doc.ContentFormat == "" {
+		doc.ContentFormat = models.ContentFormatMarkdown
+	}
+	if doc.Status == "" {
+		doc.Status = models.ArticleStatusPublished
+	}
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = time.Now()
+	}
+	if doc.UpdatedAt.IsZero() {
+		doc.UpdatedAt = doc.CreatedAt
+	}
+
+	article, err := GetArticleBySlug(doc.Slug)
+	if err != nil && !errors.Is(err, ErrArticleNotFound) {
+		return "", nil, err
+	}
+
+	result := ImportUpdated
+	if article == nil {
+		result = ImportCreated
+		article = &models.Article{}
+		if err := checkSlug(db, doc.Slug, 0); err != nil {
+			return "", nil, err
+		}
+	} else if importUnchanged(article, &doc, author.ID) {
+		return ImportUnchanged, article, nil
+	}
+
+	article.Title = doc.Title
+	article.Content = doc.Content
+	article.ContentFormat = doc.ContentFormat
+	article.Status = doc.Status
+	article.UserID = author.ID
+	if err := applyArticleMeta(article, &doc.CoverImage, &doc.Excerpt); err != nil {
+		return "", nil, err
+	}
+	article.CreatedAt = doc.CreatedAt
+	if err := renderArticle(article); err != nil {
+		return "", nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		tags, err := resolveTags(tx, doc.Tags)
+		if err != nil {
+			return err
+		}
+
+		if article.ID == 0 {
+			article.Slug = doc.Slug
+			article.Tags = tags
+			if err := tx.Create(article).Error; err != nil {
+				return utils.ErrInternal.Wrap(fmt.Errorf("创建文章失败: %w", err))
+			}
+		} else {
+			if err := bumpVersion(tx, article); err != nil {
+				return err
+			}
+			if err := changeSlug(tx, article, doc.Slug); err != nil {
+				return utils.ErrInternal.Wrap(fmt.Errorf("更新slug失败: %w", err))
+			}
+			if err := tx.Model(article).Association("Tags").Replace(tags); err != nil {
+				return utils.ErrInternal.Wrap(fmt.Errorf("更新标签失败: %w", err))
+			}
+			if err := tx.Omit("User", "Tags").Save(article).Error; err != nil {
+				return utils.ErrInternal.Wrap(fmt.Errorf("更新文章失败: %w", err))
+			}
+		}
+
+		if err := syncArticleMedia(tx, article); err != nil {
+			return utils.ErrInternal.Wrap(fmt.Errorf("更新文章引用的文件失败: %w", err))
+		}
+
+		// Save会把UpdatedAt改为当前时间，这里恢复为文件中的修改时间
+		if err := tx.Model(article).UpdateColumn("updated_at", doc.UpdatedAt).Error; err != nil {
+			return utils.ErrInternal.Wrap(fmt.Errorf("更新文章时间失败: %w", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	invalidateArticles(article.ID)
+
+	db.Preload("User").Preload("Tags").First(article, article.ID)
+
+	return result, article, nil
+}
+
+// importUnchanged 判断导入内容与已有文章是否一致
+func importUnchanged(article *models.Article, doc *ImportDocument, authorID uint) bool {
+	if article.Slug != doc.Slug || article.Title != doc.Title || article.Content != doc.Content ||
+		article.ContentFormat != doc.ContentFormat || article.Status != doc.Status || article.UserID != authorID ||
+		article.Excerpt != doc.Excerpt || article.CoverImage != doc.CoverImage ||
+		!sameSecond(article.CreatedAt, doc.CreatedAt) || !sameSecond(article.UpdatedAt, doc.UpdatedAt) {
+		return false
+	}
+
+	current := make([]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		current = append(current, tag.Slug)
+	}
+	wanted := []string{}
+	for _, name := range doc.Tags {
+		if slug := utils.Slugify(name); slug != "" && !slices.Contains(wanted, slug) {
+			wanted = append(wanted, slug)
+		}
+	}
+	slices.Sort(current)
+	slices.Sort(wanted)
+	return slices.Equal(current, wanted)
+}
+
+// sameSecond 导出文件中的时间精确到秒
+func sameSecond(a, b time.Time) bool {
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
+
+// GetExportArticles 获取所有文章用于导出，包括草稿
+func GetExportArticles() ([]models.Article, error) {
+	db := database.GetDB()
+
+	var articles []models.Article
+	if err := db.Preload("User").Preload("Tags").Order("id asc").Find(&articles).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取文章列表失败: %w", err))
+	}
+
+	return articles, nil
+}
+
+// ExportMarkdown 把文章转换为带frontmatter的Markdown文件，文章需预加载作者和标签
+func ExportMarkdown(article *models.Article) ([]byte, error) {
+	meta := &utils.Frontmatter{
+		Title:   article.Title,
+		Slug:    article.Slug,
+		Author:  utils.FirstString(article.User.Username),
+		Date:    article.CreatedAt.Format(time.RFC3339),
+		LastMod: article.UpdatedAt.Format(time.RFC3339),
+		Status:  article.Status,
+		Excerpt: article.Excerpt,
+		Image:   utils.FirstString(article.CoverImage),
+	}
+	if article.Status == models.ArticleStatusDraft {
+		// 同时写入Hugo和Jekyll的草稿标记
+		unpublished := false
+		meta.Draft = true
+		meta.Published = &unpublished
+	}
+	for _, tag := range article.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	if article.ContentFormat != models.ContentFormatMarkdown {
+		meta.Format = article.ContentFormat
+	}
+
+	return utils.FormatFrontmatter(meta, article.Content)
+}
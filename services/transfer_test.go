@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+// importDocument 导入测试使用的文章
+func importDocument() ImportDocument {
+	return ImportDocument{
+		Title:     "First",
+		Slug:      "first",
+		Content:   "# 正文",
+		Tags:      []string{"go", "misc"},
+		CreatedAt: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestImportArticle(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+
+	result, article, err := ImportArticle(importDocument(), "alice")
+	if err != nil || result != ImportCreated {
+		t.Fatalf("首次导入结果 %q，错误 %v，期望 created", result, err)
+	}
+	if article.UserID != alice.ID || article.Status != models.ArticleStatusPublished ||
+		article.ContentFormat != models.ContentFormatMarkdown || !strings.Contains(article.ContentHTML, "<h1") {
+		t.Fatalf("导入的文章 %+v", article)
+	}
+	created := loadArticle(t, article.ID)
+	if !sameSecond(created.CreatedAt, importDocument().CreatedAt) || !sameSecond(created.UpdatedAt, importDocument().UpdatedAt) {
+		t.Errorf("文章时间 %v %v，期望使用文件中的时间", created.CreatedAt, created.UpdatedAt)
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T)
+		change  func(doc *ImportDocument)
+		result  string
+		slug    string
+	}{
+		{
+			name:   "内容相同",
+			change: func(*ImportDocument) {},
+			result: ImportUnchanged,
+			slug:   "first",
+		},
+		{
+			name:   "标签顺序和大小写不同",
+			change: func(doc *ImportDocument) { doc.Tags = []string{"misc", "Go", "go"} },
+			result: ImportUnchanged,
+			slug:   "first",
+		},
+		{
+			name:   "修改标题",
+			change: func(doc *ImportDocument) { doc.Title = "First (edited)" },
+			result: ImportUpdated,
+			slug:   "first",
+		},
+		{
+			name: "文件中的slug是文章的历史slug",
+			prepare: func(t *testing.T) {
+				if _, err := UpdateArticle(article.ID, alice.ID, nil, ArticleInput{Title: "Renamed", Content: "内容", Slug: "renamed"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			change: func(*ImportDocument) {},
+			result: ImportUpdated,
+			slug:   "first",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t)
+			}
+			before := loadArticle(t, article.ID)
+			doc := importDocument()
+			tt.change(&doc)
+
+			result, imported, err := ImportArticle(doc, "alice")
+			if err != nil || result != tt.result {
+				t.Fatalf("导入结果 %q，错误 %v，期望 %q", result, err, tt.result)
+			}
+			if imported.ID != article.ID {
+				t.Fatalf("导入为新文章 %d，期望更新文章 %d", imported.ID, article.ID)
+			}
+
+			after := loadArticle(t, article.ID)
+			wantVersion := before.Version
+			if tt.result == ImportUpdated {
+				wantVersion++
+			}
+			if after.Version != wantVersion || after.Slug != tt.slug || after.Title != doc.Title {
+				t.Errorf("导入后文章 版本%d slug %s 标题 %s，期望 版本%d slug %s 标题 %s",
+					after.Version, after.Slug, after.Title, wantVersion, tt.slug, doc.Title)
+			}
+			if tags := tagSlugs(after); !slices.Equal(tags, []string{"go", "misc"}) && !slices.Equal(tags, []string{"misc", "go"}) {
+				t.Errorf("标签 %v，期望 go misc", tags)
+			}
+			if !sameSecond(after.UpdatedAt, doc.UpdatedAt) {
+				t.Errorf("修改时间 %v，期望使用文件中的时间 %v", after.UpdatedAt, doc.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestImportArticleAuthor(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+
+	tests := []struct {
+		name          string
+		slug          string
+		author        string
+		defaultAuthor string
+		want          uint
+		err           error
+	}{
+		{"使用文件中的作者", "by-bob", "bob", "alice", bob.ID, nil},
+		{"未给出作者时使用默认作者", "no-author", "", "alice", alice.ID, nil},
+		{"作者不存在时使用默认作者", "unknown-author", "nobody", "alice", alice.ID, nil},
+		{"作者不存在且没有默认作者", "no-default", "nobody", "", 0, ErrUserNotFound},
+		{"slug无效", "Not Valid", "bob", "alice", 0, ErrInvalidSlug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := importDocument()
+			doc.Slug, doc.Author = tt.slug, tt.author
+
+			_, article, err := ImportArticle(doc, tt.defaultAuthor)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("错误 %v，期望 %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil || article.UserID != tt.want {
+				t.Fatalf("文章作者 %d，错误 %v，期望 %d", article.UserID, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+
+	doc := importDocument()
+	doc.Status = models.ArticleStatusDraft
+	doc.ContentFormat = models.ContentFormatHTML
+	doc.Content = "<p>正文</p>\n"
+	doc.Excerpt = "摘要"
+	_, article, err := ImportArticle(doc, alice.Username)
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+
+	data, err := ExportMarkdown(article)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	meta, body, err := utils.ParseFrontmatter(data)
+	if err != nil {
+		t.Fatalf("解析导出的文件失败: %v", err)
+	}
+
+	// 草稿同时写入Hugo和Jekyll的标记，非Markdown格式写入format
+	if meta.Title != doc.Title || meta.Slug != doc.Slug || meta.AuthorName() != "alice" || meta.ExcerptText() != doc.Excerpt ||
+		meta.Status != models.ArticleStatusDraft || !meta.Draft || meta.Published == nil || *meta.Published ||
+		meta.Format != models.ContentFormatHTML || !slices.Equal([]string(meta.Tags), doc.Tags) {
+		t.Errorf("导出的frontmatter %+v", meta)
+	}
+	if body != doc.Content {
+		t.Errorf("导出的正文 %q，期望 %q", body, doc.Content)
+	}
+
+	// 再次导入导出的文件不做修改
+	created, _ := utils.ParseDate(meta.Date)
+	updated, _ := utils.ParseDate(meta.LastMod)
+	reimport := ImportDocument{
+		Title: meta.Title, Slug: meta.Slug, Author: meta.AuthorName(), Content: body, ContentFormat: meta.Format,
+		Tags: meta.Tags, Status: meta.Status, Excerpt: meta.ExcerptText(), CreatedAt: created, UpdatedAt: updated,
+	}
+	if result, _, err := ImportArticle(reimport, ""); err != nil || result != ImportUnchanged {
+		t.Errorf("重新导入结果 %q，错误 %v，期望 unchanged", result, err)
+	}
+}
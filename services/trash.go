@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// GetTrashArticles 获取用户回收站中的文章，最近删除的在前
+func GetTrashArticles(userID uint) ([]models.Article, error) {
+	db := database.GetDB()
+
+	var articles []models.Article
+	if err := db.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").Find(&articles).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取回收站失败: %w", err))
+	}
+
+	return articles, nil
+}
+
+// TrashPurgeTime 回收站中的文章到期永久删除的时间
+func TrashPurgeTime(article *models.Article) time.Time {
+	return article.DeletedAt.Time.AddDate(0, 0, config.AppConfig.Trash.RetentionDays)
+}
+
+// getTrashArticle 查询回收站中属于用户的文章
+func getTrashArticle(db *gorm.DB, articleID, userID uint) (*models.Article, error) {
+	var article models.Article
+	if err := db.Unscoped().First(&article, articleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询文章失败: %w", err))
+	}
+
+	// 检查权限
+	if err := checkArticleOwner(&article, userID); err != nil {
+		return nil, err
+	}
+	if !article.DeletedAt.Valid {
+		return nil, ErrNotInTrash
+	}
+
+	return &article, nil
+}
+
+// RestoreArticle 从回收站恢复文章
+func RestoreArticle(articleID, userID uint) (*models.Article, error) {
+	db := database.GetDB()
+
+	article, err := getTrashArticle(db, articleID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Unscoped().Model(article).UpdateColumn("deleted_at", nil).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("恢复文章失败: %w", err))
+	}
+	invalidateArticles(article.ID)
+
+	return GetArticleByID(article.ID)
+}
+
+// PurgeArticle 永久删除回收站中的文章
+func PurgeArticle(articleID, userID uint) error {
+	db := database.GetDB()
+
+	article, err := getTrashArticle(db, articleID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return purgeArticles(tx, []uint{article.ID})
+	}); err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("永久删除文章失败: %w", err))
+	}
+
+	return nil
+}
+
+// EmptyTrash 清空用户的回收站，返回永久删除的文章数
+func EmptyTrash(userID uint) (int, error) {
+	db := database.GetDB()
+
+	var ids []uint
+	if err := db.Unscoped().Model(&models.Article{}).Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, utils.ErrInternal.Wrap(fmt.Errorf("查询回收站失败: %w", err))
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return purgeArticles(tx, ids)
+	}); err != nil {
+		return 0, utils.ErrInternal.Wrap(fmt.Errorf("清空回收站失败: %w", err))
+	}
+
+	return len(ids), nil
+}
+
+// PurgeExpiredTrash 永久删除在回收站中超过 trash.retention_days 天的文章，dryRun为true时只返回待删除的文章
+func PurgeExpiredTrash(dryRun bool) ([]models.Article, error) {
+	db := database.GetDB()
+
+	cutoff := time.Now().AddDate(0, 0, -config.AppConfig.Trash.RetentionDays)
+
+	var articles []models.Article
+	if err := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("id asc").Find(&articles).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询过期文章失败: %w", err))
+	}
+
+	if dryRun || len(articles) == 0 {
+		return articles, nil
+	}
+
+	ids := make([]uint, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return purgeArticles(tx, ids)
+	}); err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("清理回收站失败: %w", err))
+	}
+
+	return articles, nil
+}
+
+// StartTrashPurge 启动后台协程，每隔 trash.purge_interval 小时清理一次过期的回收站文章
+func StartTrashPurge() {
+	interval := config.AppConfig.Trash.PurgeInterval
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			articles, err := PurgeExpiredTrash(false)
+			if err != nil {
+				log.Printf("清理回收站失败: %v\n", err)
+			} else if len(articles) > 0 {
+				log.Printf("已永久删除回收站中的 %d 篇过期文章\n", len(articles))
+			}
+			<-ticker.C
+		}
+	}()
+}
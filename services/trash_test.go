@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"gorm.io/gorm"
+)
+
+// trashArticle 创建文章并移入回收站
+func trashArticle(t *testing.T, userID uint, title string, tags ...string) *models.Article {
+	t.Helper()
+	article := createArticle(t, userID, title, tags...)
+	if err := DeleteArticle(article.ID, userID, nil); err != nil {
+		t.Fatalf("删除文章失败: %v", err)
+	}
+	return article
+}
+
+// articleExists 文章是否仍在数据库中（包括回收站）
+func articleExists(articleID uint) bool {
+	var count int64
+	database.GetDB().Unscoped().Model(&models.Article{}).Where("id = ?", articleID).Count(&count)
+	return count > 0
+}
+
+func TestRestoreArticle(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+	article := trashArticle(t, alice.ID, "trashed")
+
+	if _, err := GetArticleByID(article.ID); !errors.Is(err, ErrArticleNotFound) {
+		t.Fatalf("回收站中的文章仍可访问: %v", err)
+	}
+	if trash, err := GetTrashArticles(alice.ID); err != nil || len(trash) != 1 || trash[0].ID != article.ID {
+		t.Fatalf("回收站 %v，错误 %v", trash, err)
+	}
+
+	if _, err := RestoreArticle(article.ID, bob.ID); !errors.Is(err, ErrArticleForbidden) {
+		t.Errorf("恢复其他用户的文章时错误 %v，期望 ErrArticleForbidden", err)
+	}
+
+	restored, err := RestoreArticle(article.ID, alice.ID)
+	if err != nil || restored.ID != article.ID {
+		t.Fatalf("恢复文章失败: %v", err)
+	}
+	if _, err := GetArticleByID(article.ID); err != nil {
+		t.Errorf("恢复后无法访问文章: %v", err)
+	}
+	if trash, _ := GetTrashArticles(alice.ID); len(trash) != 0 {
+		t.Errorf("恢复后回收站仍有 %d 篇文章", len(trash))
+	}
+
+	// 不在回收站中的文章不能恢复或永久删除
+	if _, err := RestoreArticle(article.ID, alice.ID); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("重复恢复时错误 %v，期望 ErrNotInTrash", err)
+	}
+	if err := PurgeArticle(article.ID, alice.ID); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("永久删除未删除的文章时错误 %v，期望 ErrNotInTrash", err)
+	}
+}
+
+func TestPurgeArticle(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+	db := database.GetDB()
+
+	article := createArticle(t, alice.ID, "old", "go")
+	if _, err := UpdateArticle(article.ID, alice.ID, nil, ArticleInput{Title: "new", Content: "内容", Slug: "new-slug", Tags: []string{"go"}}); err != nil {
+		t.Fatalf("修改文章失败: %v", err)
+	}
+	comment := createComment(t, article.ID, bob)
+	if err := DeleteArticle(article.ID, alice.ID, nil); err != nil {
+		t.Fatalf("删除文章失败: %v", err)
+	}
+
+	if err := PurgeArticle(article.ID, bob.ID); !errors.Is(err, ErrArticleForbidden) {
+		t.Fatalf("永久删除其他用户的文章时错误 %v，期望 ErrArticleForbidden", err)
+	}
+	if err := PurgeArticle(article.ID, alice.ID); err != nil {
+		t.Fatalf("永久删除文章失败: %v", err)
+	}
+
+	// 文章和评论、标签关联、历史slug一起删除
+	if articleExists(article.ID) {
+		t.Errorf("文章未删除")
+	}
+	counts := map[string]*gorm.DB{
+		"评论":     db.Unscoped().Model(&models.Comment{}).Where("id = ?", comment.ID),
+		"标签关联":   db.Table("article_tags").Where("article_id = ?", article.ID),
+		"历史slug": db.Unscoped().Model(&models.ArticleSlug{}).Where("article_id = ?", article.ID),
+	}
+	for name, query := range counts {
+		var count int64
+		if query.Count(&count); count != 0 {
+			t.Errorf("%s未删除，剩余 %d 条", name, count)
+		}
+	}
+	if _, err := GetArticleBySlug("old"); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("历史slug仍能找到文章: %v", err)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	bob := createUser(t, "bob")
+	first := trashArticle(t, alice.ID, "first")
+	second := trashArticle(t, alice.ID, "second")
+	kept := createArticle(t, alice.ID, "kept")
+	bobs := trashArticle(t, bob.ID, "bob's")
+
+	count, err := EmptyTrash(alice.ID)
+	if err != nil || count != 2 {
+		t.Fatalf("清空回收站删除 %d 篇，错误 %v，期望2篇", count, err)
+	}
+	if articleExists(first.ID) || articleExists(second.ID) {
+		t.Errorf("回收站中的文章未删除")
+	}
+	// 未删除的文章和其他用户的回收站不受影响
+	if !articleExists(kept.ID) || !articleExists(bobs.ID) {
+		t.Errorf("清空回收站删除了其他文章")
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	expired := trashArticle(t, alice.ID, "expired")
+	recent := trashArticle(t, alice.ID, "recent")
+	published := createArticle(t, alice.ID, "published")
+
+	days := config.AppConfig.Trash.RetentionDays
+	database.GetDB().Unscoped().Model(&models.Article{}).Where("id = ?", expired.ID).
+		UpdateColumn("deleted_at", time.Now().AddDate(0, 0, -days).Add(-time.Minute))
+
+	articles, err := PurgeExpiredTrash(true)
+	if err != nil || len(articles) != 1 || articles[0].ID != expired.ID {
+		t.Fatalf("dryRun 返回 %v，错误 %v，期望只有过期的文章", articles, err)
+	}
+	if !articleExists(expired.ID) {
+		t.Fatalf("dryRun 删除了文章")
+	}
+
+	if articles, err = PurgeExpiredTrash(false); err != nil || len(articles) != 1 {
+		t.Fatalf("清理回收站返回 %d 篇，错误 %v", len(articles), err)
+	}
+	if articleExists(expired.ID) {
+		t.Errorf("过期的文章未删除")
+	}
+	if !articleExists(recent.ID) || !articleExists(published.ID) {
+		t.Errorf("未过期的文章被删除")
+	}
+}
@@ -1,8 +1,16 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/dingdinglz/test-blog/config"
 	"github.com/dingdinglz/test-blog/database"
 	"github.com/dingdinglz/test-blog/models"
 	"github.com/dingdinglz/test-blog/utils"
@@ -13,21 +21,24 @@ import (
 func Register(username, password, email string) (*models.User, error) {
 	db := database.GetDB()
 
-	// 检查用户名是否已存在
+	// 检查用户名是否已存在，匿名用户的用户名保留不能注册
 	var existUser models.User
+	if username == config.AppConfig.Account.GhostUsername {
+		return nil, ErrUsernameTaken
+	}
 	if err := db.Where("username = ?", username).First(&existUser).Error; err == nil {
-		return nil, errors.New("用户名已存在")
+		return nil, ErrUsernameTaken
 	}
 
 	// 检查邮箱是否已存在
 	if err := db.Where("email = ?", email).First(&existUser).Error; err == nil {
-		return nil, errors.New("邮箱已被注册")
+		return nil, ErrEmailTaken
 	}
 
 	// 加密密码
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		return nil, errors.New("密码加密失败")
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("密码加密失败: %w", err))
 	}
 
 	// 创建用户
@@ -38,7 +49,7 @@ func Register(username, password, email string) (*models.User, error) {
 	}
 
 	if err := db.Create(user).Error; err != nil {
-		return nil, errors.New("用户创建失败")
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("用户创建失败: %w", err))
 	}
 
 	return user, nil
@@ -51,37 +62,293 @@ func Login(username, password string) (string, *models.User, error) {
 	// 查找用户
 	var user models.User
 	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return "", nil, errors.New("用户不存在")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, utils.ErrInvalidCredentials
 		}
-		return "", nil, errors.New("查询用户失败")
+		return "", nil, utils.ErrInternal.Wrap(fmt.Errorf("查询用户失败: %w", err))
 	}
 
 	// 验证密码
 	if !utils.CheckPassword(user.Password, password) {
-		return "", nil, errors.New("密码错误")
+		return "", nil, utils.ErrInvalidCredentials
 	}
 
 	// 生成token
 	token, err := utils.GenerateToken(user.ID, user.Username)
 	if err != nil {
-		return "", nil, errors.New("生成token失败")
+		return "", nil, utils.ErrInternal.Wrap(fmt.Errorf("生成token失败: %w", err))
 	}
 
 	return token, &user, nil
 }
 
+// randomPassword 生成无法登录的随机密码哈希，用于导入的用户和匿名用户
+func randomPassword() (string, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return utils.HashPassword(hex.EncodeToString(secret))
+}
+
 // GetUserByID 根据ID获取用户信息
 func GetUserByID(userID uint) (*models.User, error) {
 	db := database.GetDB()
 
 	var user models.User
 	if err := db.First(&user, userID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, errors.New("用户不存在")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询用户失败: %w", err))
+	}
+
+	return &user, nil
+}
+
+// GetUserByUsername 根据用户名获取用户信息
+func GetUserByUsername(username string) (*models.User, error) {
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
 		}
-		return nil, errors.New("查询用户失败")
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询用户失败: %w", err))
 	}
 
 	return &user, nil
 }
+
+// GetAllUsers 获取所有用户
+func GetAllUsers() ([]models.User, error) {
+	db := database.GetDB()
+
+	var users []models.User
+	if err := db.Order("id asc").Find(&users).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取用户列表失败: %w", err))
+	}
+
+	return users, nil
+}
+
+// Viewer 发起请求的用户，用于判断能否查看他人的个人信息
+type Viewer struct {
+	ID    uint // 为0表示未登录
+	Admin bool
+}
+
+// GetViewer 根据token中的用户ID获取请求用户，用户不存在时按未登录处理
+func GetViewer(userID uint) Viewer {
+	if userID == 0 {
+		return Viewer{}
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return Viewer{}
+	}
+	return Viewer{ID: user.ID, Admin: user.Role == models.UserRoleAdmin}
+}
+
+// CanViewEmail 邮箱只对本人、管理员可见，用户设置公开时所有人可见
+func CanViewEmail(user *models.User, viewer Viewer) bool {
+	return user.ShowEmail || viewer.Admin || (viewer.ID != 0 && viewer.ID == user.ID)
+}
+
+// UpdatePrivacy 更新用户的隐私设置
+func UpdatePrivacy(userID uint, showEmail bool) (*models.User, error) {
+	db := database.GetDB()
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(user).Update("show_email", showEmail).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("更新隐私设置失败: %w", err))
+	}
+	invalidateArticles()
+
+	return user, nil
+}
+
+// ProfileInput 个人资料的可编辑字段，为nil的字段保持不变
+type ProfileInput struct {
+	DisplayName   *string
+	Bio           *string
+	Website       *string // 空字符串清除网站
+	Avatar        *string // 头像图片地址，空字符串清除头像
+	AvatarMediaID *uint   // 使用自己上传的图片作为头像，优先于Avatar，为0时清除头像
+	SocialLinks   []models.SocialLink
+}
+
+// UpdateProfile 更新用户的个人资料
+func UpdateProfile(userID uint, input ProfileInput) (*models.User, error) {
+	db := database.GetDB()
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*input.DisplayName)
+	}
+	if input.Bio != nil {
+		user.Bio = strings.TrimSpace(*input.Bio)
+	}
+	if input.Website != nil {
+		website := strings.TrimSpace(*input.Website)
+		if website != "" && !utils.ValidWebURL(website) {
+			return nil, ErrInvalidURL
+		}
+		user.Website = website
+	}
+	if input.SocialLinks != nil {
+		links := make([]models.SocialLink, 0, len(input.SocialLinks))
+		for _, link := range input.SocialLinks {
+			link.Name = strings.TrimSpace(link.Name)
+			link.URL = strings.TrimSpace(link.URL)
+			if !utils.ValidWebURL(link.URL) {
+				return nil, ErrInvalidURL
+			}
+			links = append(links, link)
+		}
+		user.SocialLinks = links
+	}
+
+	// 设置头像，上传的图片优先
+	switch {
+	case input.AvatarMediaID != nil && *input.AvatarMediaID != 0:
+		media, err := avatarMedia(*input.AvatarMediaID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		user.Avatar = MediaURL(media.Key)
+		user.AvatarMediaID = &media.ID
+	case input.AvatarMediaID != nil:
+		user.Avatar = ""
+		user.AvatarMediaID = nil
+	case input.Avatar != nil:
+		avatar := strings.TrimSpace(*input.Avatar)
+		if avatar != "" && !utils.ValidImageURL(avatar) {
+			return nil, ErrInvalidAvatar
+		}
+		user.Avatar = avatar
+		user.AvatarMediaID = nil
+	}
+
+	if err := db.Model(user).Select("display_name", "bio", "website", "social_links", "avatar", "avatar_media_id").
+		Updates(user).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("更新个人资料失败: %w", err))
+	}
+
+	// 文章响应中包含作者资料
+	invalidateArticles()
+
+	return user, nil
+}
+
+// avatarMedia 查询用作头像的文件，只能使用自己上传的图片
+func avatarMedia(mediaID, userID uint) (*models.Media, error) {
+	db := database.GetDB()
+
+	var media models.Media
+	if err := db.First(&media, mediaID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("查询文件失败: %w", err))
+	}
+	if media.UserID != userID {
+		return nil, ErrMediaForbidden
+	}
+	if !strings.HasPrefix(media.MimeType, "image/") {
+		return nil, ErrInvalidAvatar
+	}
+	return &media, nil
+}
+
+// AvatarURL 用户实际显示的头像，未设置时根据邮箱哈希生成 avatar.gravatar 的头像地址，未配置时为空
+func AvatarURL(user *models.User) string {
+	if user.Avatar != "" {
+		return user.Avatar
+	}
+
+	cfg := config.AppConfig.Avatar
+	if cfg.Gravatar == "" || user.Email == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(user.Email))))
+	query := url.Values{}
+	if cfg.Default != "" {
+		query.Set("d", cfg.Default)
+	}
+	if cfg.Size > 0 {
+		query.Set("s", strconv.Itoa(cfg.Size))
+	}
+	link := strings.TrimSuffix(cfg.Gravatar, "/") + "/" + hex.EncodeToString(hash[:])
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+// UserProfile 公开的个人主页资料
+type UserProfile struct {
+	User         *models.User
+	ArticleCount int64 // 已发布的文章数
+	DraftCount   int64
+}
+
+// GetUserProfile 根据用户名获取个人主页资料和文章数
+func GetUserProfile(username string) (*UserProfile, error) {
+	db := database.GetDB()
+
+	user, err := GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&models.Article{}).Select("status, count(*) AS count").
+		Where("user_id = ?", user.ID).Group("status").Scan(&counts).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("统计文章数失败: %w", err))
+	}
+
+	profile := &UserProfile{User: user}
+	for _, c := range counts {
+		switch c.Status {
+		case models.ArticleStatusPublished:
+			profile.ArticleCount = c.Count
+		case models.ArticleStatusDraft:
+			profile.DraftCount = c.Count
+		}
+	}
+	return profile, nil
+}
+
+// SetUserRole 设置用户角色
+func SetUserRole(username, role string) error {
+	db := database.GetDB()
+
+	if role != models.UserRoleUser && role != models.UserRoleAdmin {
+		return fmt.Errorf("未知的角色: %s", role)
+	}
+
+	user, err := GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Model(user).Update("role", role).Error; err != nil {
+		return utils.ErrInternal.Wrap(fmt.Errorf("更新用户角色失败: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/storage"
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+// UserExport 用户的全部数据，包括已删除但尚未永久清除的文章和评论
+type UserExport struct {
+	User     *models.User
+	Articles []models.Article
+	Comments []models.Comment
+	Media    []models.Media
+}
+
+// exportProfile 导出的账户信息
+type exportProfile struct {
+	ID          uint                `json:"id"`
+	Username    string              `json:"username"`
+	Email       string              `json:"email"`
+	DisplayName string              `json:"display_name"`
+	Bio         string              `json:"bio"`
+	Avatar      string              `json:"avatar"`
+	Website     string              `json:"website"`
+	SocialLinks []models.SocialLink `json:"social_links"`
+	Role        string              `json:"role"`
+	ShowEmail   bool                `json:"show_email"`
+	DeleteAt    *string             `json:"delete_at"`
+	CreatedAt   string              `json:"created_at"`
+	UpdatedAt   string              `json:"updated_at"`
+}
+
+// exportArticle 导出的文章，Markdown文件为 File 指向的路径
+type exportArticle struct {
+	ID            uint     `json:"id"`
+	Title         string   `json:"title"`
+	Slug          string   `json:"slug"`
+	Content       string   `json:"content"`
+	ContentFormat string   `json:"content_format"`
+	Excerpt       string   `json:"excerpt"`
+	CoverImage    string   `json:"cover_image"`
+	Status        string   `json:"status"`
+	Tags          []string `json:"tags"`
+	File          string   `json:"file"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+	DeletedAt     *string  `json:"deleted_at"`
+}
+
+// exportComment 导出的评论
+type exportComment struct {
+	ID          uint    `json:"id"`
+	ArticleID   uint    `json:"article_id"`
+	ParentID    *uint   `json:"parent_id"`
+	AuthorName  string  `json:"author_name"`
+	AuthorEmail string  `json:"author_email"`
+	AuthorURL   string  `json:"author_url"`
+	Content     string  `json:"content"`
+	Status      string  `json:"status"`
+	CreatedAt   string  `json:"created_at"`
+	DeletedAt   *string `json:"deleted_at"`
+}
+
+// exportMedia 导出的文件，原文件为 File 指向的路径
+type exportMedia struct {
+	ID        uint   `json:"id"`
+	URL       string `json:"url"`
+	Filename  string `json:"filename"`
+	MimeType  string `json:"mime_type"`
+	Size      int64  `json:"size"`
+	File      string `json:"file"`
+	CreatedAt string `json:"created_at"`
+}
+
+// PrepareUserExport 查询用户的全部数据用于导出
+func PrepareUserExport(userID uint) (*UserExport, error) {
+	db := database.GetDB()
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &UserExport{User: user}
+	if err := db.Unscoped().Preload("User").Preload("Tags").Where("user_id = ?", userID).
+		Order("id asc").Find(&export.Articles).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取文章失败: %w", err))
+	}
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("id asc").Find(&export.Comments).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取评论失败: %w", err))
+	}
+	if err := db.Where("user_id = ?", userID).Order("id asc").Find(&export.Media).Error; err != nil {
+		return nil, utils.ErrInternal.Wrap(fmt.Errorf("获取文件失败: %w", err))
+	}
+
+	return export, nil
+}
+
+// WriteZip 把导出数据写入ZIP：profile.json、articles.json、comments.json、media.json，
+// articles/ 下为Markdown格式的文章（已删除的在 articles/deleted/ 下），media/ 下为上传的原文件
+func (e *UserExport) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	user := e.User
+	profile := exportProfile{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Avatar:      user.Avatar,
+		Website:     user.Website,
+		SocialLinks: user.SocialLinks,
+		Role:        user.Role,
+		ShowEmail:   user.ShowEmail,
+		CreatedAt:   user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   user.UpdatedAt.Format(time.RFC3339),
+	}
+	if user.DeleteAt != nil {
+		deleteAt := user.DeleteAt.Format(time.RFC3339)
+		profile.DeleteAt = &deleteAt
+	}
+	if err := writeZipJSON(zw, "profile.json", profile); err != nil {
+		return err
+	}
+
+	articles := make([]exportArticle, 0, len(e.Articles))
+	for i := range e.Articles {
+		article := &e.Articles[i]
+		item := exportArticle{
+			ID:            article.ID,
+			Title:         article.Title,
+			Slug:          article.Slug,
+			Content:       article.Content,
+			ContentFormat: article.ContentFormat,
+			Excerpt:       article.Excerpt,
+			CoverImage:    article.CoverImage,
+			Status:        article.Status,
+			Tags:          []string{},
+			CreatedAt:     article.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:     article.UpdatedAt.Format(time.RFC3339),
+		}
+		for _, tag := range article.Tags {
+			item.Tags = append(item.Tags, tag.Name)
+		}
+
+		name := article.Slug
+		if name == "" {
+			name = fmt.Sprintf("article-%d", article.ID)
+		}
+		item.File = "articles/" + name + ".md"
+		if article.DeletedAt.Valid {
+			deletedAt := article.DeletedAt.Time.Format(time.RFC3339)
+			item.DeletedAt = &deletedAt
+			item.File = "articles/deleted/" + name + ".md"
+		}
+
+		data, err := ExportMarkdown(article)
+		if err != nil {
+			return err
+		}
+		if err := writeZipFile(zw, item.File, data); err != nil {
+			return err
+		}
+		articles = append(articles, item)
+	}
+	if err := writeZipJSON(zw, "articles.json", articles); err != nil {
+		return err
+	}
+
+	comments := make([]exportComment, 0, len(e.Comments))
+	for _, comment := range e.Comments {
+		item := exportComment{
+			ID:          comment.ID,
+			ArticleID:   comment.ArticleID,
+			ParentID:    comment.ParentID,
+			AuthorName:  comment.AuthorName,
+			AuthorEmail: comment.AuthorEmail,
+			AuthorURL:   comment.AuthorURL,
+			Content:     comment.Content,
+			Status:      comment.Status,
+			CreatedAt:   comment.CreatedAt.Format(time.RFC3339),
+		}
+		if comment.DeletedAt.Valid {
+			deletedAt := comment.DeletedAt.Time.Format(time.RFC3339)
+			item.DeletedAt = &deletedAt
+		}
+		comments = append(comments, item)
+	}
+	if err := writeZipJSON(zw, "comments.json", comments); err != nil {
+		return err
+	}
+
+	media := make([]exportMedia, 0, len(e.Media))
+	for _, m := range e.Media {
+		item := exportMedia{
+			ID:        m.ID,
+			URL:       MediaURL(m.Key),
+			Filename:  m.Filename,
+			MimeType:  m.MimeType,
+			Size:      m.Size,
+			File:      path.Join("media", m.Key),
+			CreatedAt: m.CreatedAt.Format(time.RFC3339),
+		}
+		if err := copyMediaToZip(zw, item.File, m.Key); err != nil {
+			return err
+		}
+		media = append(media, item)
+	}
+	if err := writeZipJSON(zw, "media.json", media); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeZipJSON 把v以缩进的JSON格式写入ZIP
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(zw, name, data)
+}
+
+// writeZipFile 写入ZIP中的一个文件
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// copyMediaToZip 从存储中读取文件写入ZIP，上传的文件多为已压缩的格式，不再压缩
+func copyMediaToZip(zw *zip.Writer, name, key string) error {
+	r, err := storage.GetStorage().Get(context.Background(), key)
+	if err != nil {
+		return fmt.Errorf("读取文件 %s 失败: %w", key, err)
+	}
+	defer r.Close()
+
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
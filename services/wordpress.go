@@ -0,0 +1,618 @@
+package services
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/utils"
+	"gorm.io/gorm"
+)
+
+// wxrDateLayout WXR中的日期格式
+const wxrDateLayout = "2006-01-02 15:04:05"
+
+// wxrNoDate 未发布的文章没有GMT时间
+const wxrNoDate = "0000-00-00 00:00:00"
+
+// wxrAuthor WXR中的 wp:author
+type wxrAuthor struct {
+	ID          string `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+// wxrCategory 文章的分类或标签
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+// wxrComment WXR中的 wp:comment
+type wxrComment struct {
+	ID       string `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	Email    string `xml:"comment_author_email"`
+	URL      string `xml:"comment_author_url"`
+	Date     string `xml:"comment_date"`
+	DateGMT  string `xml:"comment_date_gmt"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Type     string `xml:"comment_type"`
+	Parent   string `xml:"comment_parent"`
+	UserID   string `xml:"comment_user_id"`
+}
+
+// wxrItem WXR中的 item，包括文章、页面、附件等
+type wxrItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        string        `xml:"guid"`
+	Creator     string        `xml:"creator"`
+	Content     string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Excerpt     string        `xml:"http://wordpress.org/export/1.2/excerpt/ encoded"`
+	PostID      string        `xml:"post_id"`
+	PostDate    string        `xml:"post_date"`
+	PostDateGMT string        `xml:"post_date_gmt"`
+	Modified    string        `xml:"post_modified"`
+	ModifiedGMT string        `xml:"post_modified_gmt"`
+	PostName    string        `xml:"post_name"`
+	Status      string        `xml:"status"`
+	PostType    string        `xml:"post_type"`
+	Categories  []wxrCategory `xml:"category"`
+	Comments    []wxrComment  `xml:"comment"`
+}
+
+// WordPressOptions WordPress导入选项
+type WordPressOptions struct {
+	DryRun        bool                          // 只生成报告，不写入数据库
+	DefaultAuthor string                        // 文章作者不在导出文件中时使用的用户名
+	Progress      func(items int, offset int64) // 每处理一个item后调用，offset为已读取的字节数
+}
+
+// WordPressReport WordPress导入报告
+type WordPressReport struct {
+	Source          string
+	Items           int
+	UsersCreated    int
+	UsersExisting   int
+	ArticlesCreated int
+	ArticlesDraft   int
+	ArticlesSkipped int // 之前已导入
+	CommentsCreated int
+	CommentsPending int
+	CommentsSkipped int // 之前已导入
+	Tags            int
+	Redirects       int
+	Ignored         map[string]int // 未导入的内容类型及数量
+	Failed          int
+	Warnings        []string
+}
+
+// wpImporter 单次导入的状态
+type wpImporter struct {
+	db      *gorm.DB
+	opts    WordPressOptions
+	report  *WordPressReport
+	host    string
+	users   map[string]uint // 登录名 -> 本地用户ID
+	userIDs map[string]uint // WordPress用户ID -> 本地用户ID
+	tags    map[string]bool
+}
+
+// ImportWordPress 从WXR导出文件导入用户、文章、评论和标签
+// 导入记录保存在数据库中，每篇文章及其评论在同一事务中提交，中断后重新执行会跳过已导入的内容
+func ImportWordPress(r io.Reader, opts WordPressOptions) (*WordPressReport, error) {
+	db := database.GetDB()
+
+	// 试运行在事务中执行全部操作后回滚，报告与实际导入一致
+	if opts.DryRun {
+		db = db.Begin()
+		if db.Error != nil {
+			return nil, utils.ErrInternal.Wrap(fmt.Errorf("开启事务失败: %w", db.Error))
+		}
+		defer db.Rollback()
+	}
+
+	im := &wpImporter{
+		db:      db,
+		opts:    opts,
+		report:  &WordPressReport{Ignored: map[string]int{}},
+		users:   map[string]uint{},
+		userIDs: map[string]uint{},
+		tags:    map[string]bool{},
+	}
+
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	// rss > channel > link/wp:author/item
+	depth := 0
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return im.report, fmt.Errorf("解析WXR文件失败: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth != 3 {
+				continue
+			}
+
+			switch t.Name.Local {
+			case "link":
+				var link string
+				if err := decoder.DecodeElement(&link, &t); err != nil {
+					return im.report, fmt.Errorf("解析站点地址失败: %w", err)
+				}
+				if im.report.Source == "" {
+					if err := im.setSource(link); err != nil {
+						return im.report, err
+					}
+				}
+			case "author":
+				var author wxrAuthor
+				if err := decoder.DecodeElement(&author, &t); err != nil {
+					return im.report, fmt.Errorf("解析作者失败: %w", err)
+				}
+				im.importAuthor(&author)
+			case "item":
+				var item wxrItem
+				if err := decoder.DecodeElement(&item, &t); err != nil {
+					return im.report, fmt.Errorf("解析文章失败: %w", err)
+				}
+				if im.report.Source == "" {
+					return im.report, errors.New("WXR文件缺少站点地址")
+				}
+				im.importItem(&item)
+
+				im.report.Items++
+				if opts.Progress != nil {
+					opts.Progress(im.report.Items, decoder.InputOffset())
+				}
+			default:
+				continue
+			}
+			// DecodeElement 已读取结束标签
+			depth--
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	im.report.Tags = len(im.tags)
+	if !opts.DryRun {
+		invalidateArticles()
+	}
+	return im.report, nil
+}
+
+// setSource 以站点地址作为导入来源，同一站点重复导入时据此识别已导入的内容
+func (im *wpImporter) setSource(link string) error {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("无效的站点地址: %s", link)
+	}
+	im.host = u.Host
+	im.report.Source = strings.TrimSuffix(u.Host+u.Path, "/")
+	return nil
+}
+
+// warn 记录警告
+func (im *wpImporter) warn(format string, args ...interface{}) {
+	im.report.Warnings = append(im.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// lookup 查询之前导入的本地记录ID
+func (im *wpImporter) lookup(tx *gorm.DB, kind, externalID string) (uint, bool, error) {
+	// 使用Find避免大量未导入记录产生"record not found"日志
+	var item models.ImportedItem
+	result := tx.Where("source = ? AND kind = ? AND external_id = ?", im.report.Source, kind, externalID).Limit(1).Find(&item)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	return item.LocalID, result.RowsAffected > 0, nil
+}
+
+// record 保存导入记录
+func (im *wpImporter) record(tx *gorm.DB, kind, externalID string, localID uint) error {
+	return tx.Create(&models.ImportedItem{
+		Source:     im.report.Source,
+		Kind:       kind,
+		ExternalID: externalID,
+		LocalID:    localID,
+	}).Error
+}
+
+// importAuthor 导入作者，用户名或邮箱已存在时使用已有用户，否则创建随机密码的新用户
+func (im *wpImporter) importAuthor(author *wxrAuthor) {
+	login := strings.TrimSpace(author.Login)
+	if login == "" {
+		return
+	}
+
+	err := im.db.Transaction(func(tx *gorm.DB) error {
+		id, ok, err := im.lookup(tx, models.ImportKindUser, login)
+		if err != nil {
+			return err
+		}
+		if ok {
+			im.users[login] = id
+			im.report.UsersExisting++
+			return nil
+		}
+
+		var user models.User
+		query := tx.Where("username = ?", login)
+		if author.Email != "" {
+			query = query.Or("email = ?", author.Email)
+		}
+		result := query.Limit(1).Find(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			im.report.UsersExisting++
+		} else {
+			if user, err = im.createUser(tx, login, author.Email, author.DisplayName); err != nil {
+				return err
+			}
+			im.report.UsersCreated++
+		}
+
+		im.users[login] = user.ID
+		return im.record(tx, models.ImportKindUser, login, user.ID)
+	})
+	if err != nil {
+		im.report.Failed++
+		im.warn("作者 %s: %v", login, err)
+		return
+	}
+
+	if author.ID != "" {
+		im.userIDs[author.ID] = im.users[login]
+	}
+}
+
+// createUser 创建导入的用户，密码随机生成，没有邮箱时使用占位邮箱
+func (im *wpImporter) createUser(tx *gorm.DB, login, email, displayName string) (models.User, error) {
+	password, err := randomPassword()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if email == "" {
+		email = login + "@wordpress.invalid"
+	}
+
+	user := models.User{Username: login, Password: password, Email: email, DisplayName: strings.TrimSpace(displayName)}
+	if err := tx.Create(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// resolveAuthor 查找文章作者，导出文件中没有的作者按用户名匹配已有用户，都没有时使用默认作者
+func (im *wpImporter) resolveAuthor(tx *gorm.DB, login string) (uint, error) {
+	if id, ok := im.users[login]; ok {
+		return id, nil
+	}
+
+	for _, username := range []string{login, im.opts.DefaultAuthor} {
+		if username == "" {
+			continue
+		}
+		var user models.User
+		result := tx.Select("id").Where("username = ?", username).Limit(1).Find(&user)
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected > 0 {
+			im.users[login] = user.ID
+			return user.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("作者 %q 不存在，可使用 -author 指定默认作者", login)
+}
+
+// wpArticleStatus WordPress文章状态对应的本地状态，返回空字符串表示不导入
+func wpArticleStatus(status string) string {
+	switch status {
+	case "publish":
+		return models.ArticleStatusPublished
+	case "draft", "pending", "private", "future":
+		return models.ArticleStatusDraft
+	default:
+		return ""
+	}
+}
+
+// importItem 导入一篇文章及其评论，非文章类型和回收站中的文章计入未导入
+func (im *wpImporter) importItem(item *wxrItem) {
+	if item.PostType != "post" {
+		im.report.Ignored[item.PostType]++
+		return
+	}
+	status := wpArticleStatus(item.Status)
+	if status == "" {
+		im.report.Ignored["post:"+item.Status]++
+		return
+	}
+
+	err := im.db.Transaction(func(tx *gorm.DB) error {
+		articleID, ok, err := im.lookup(tx, models.ImportKindArticle, item.PostID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			im.report.ArticlesSkipped++
+		} else {
+			if articleID, err = im.createArticle(tx, item, status); err != nil {
+				return err
+			}
+		}
+
+		if err := im.importRedirects(tx, item, articleID); err != nil {
+			return err
+		}
+		return im.importComments(tx, item, articleID)
+	})
+	if err != nil {
+		im.report.Failed++
+		im.warn("文章 %q (ID %s): %v", item.Title, item.PostID, err)
+	}
+}
+
+// createArticle 创建文章，保留发布时间，slug冲突时追加数字后缀
+func (im *wpImporter) createArticle(tx *gorm.DB, item *wxrItem, status string) (uint, error) {
+	userID, err := im.resolveAuthor(tx, strings.TrimSpace(item.Creator))
+	if err != nil {
+		return 0, err
+	}
+
+	name, _ := url.PathUnescape(item.PostName)
+	name = strings.ToLower(name)
+
+	title := strings.TrimSpace(item.Title)
+	if title == "" {
+		title = name
+	}
+	if title == "" {
+		title = "wordpress-" + item.PostID
+	}
+
+	// 优先使用原有的slug
+	var slug string
+	if utils.ValidSlug(name) {
+		if taken, err := slugTaken(tx, name, 0); err != nil {
+			return 0, err
+		} else if !taken {
+			slug = name
+		}
+	}
+	if slug == "" {
+		source := name
+		if source == "" {
+			source = title
+		}
+		if slug, err = uniqueSlug(tx, source, 0); err != nil {
+			return 0, err
+		}
+		if utils.ValidSlug(name) {
+			im.warn("文章 %q 的slug %s 已被占用，改为 %s", title, name, slug)
+		}
+	}
+
+	createdAt := wxrTime(item.PostDateGMT, item.PostDate)
+	updatedAt := wxrTime(item.ModifiedGMT, item.Modified)
+	if updatedAt.Before(createdAt) {
+		updatedAt = createdAt
+	}
+
+	article := &models.Article{
+		Title:         title,
+		Slug:          slug,
+		Content:       wpautop(item.Content),
+		ContentFormat: models.ContentFormatHTML,
+		Excerpt:       utils.PlainText(item.Excerpt),
+		Status:        status,
+		UserID:        userID,
+	}
+	article.CreatedAt = createdAt
+	article.UpdatedAt = updatedAt
+	if err := renderArticle(article); err != nil {
+		return 0, err
+	}
+
+	// 分类和标签都作为标签导入，忽略WordPress的默认分类
+	var names []string
+	for _, category := range item.Categories {
+		if (category.Domain != "category" && category.Domain != "post_tag") || category.Nicename == "uncategorized" {
+			continue
+		}
+		if utils.Slugify(category.Name) == "" {
+			im.warn("文章 %q 的标签 %q 无法生成slug，已忽略", title, category.Name)
+			continue
+		}
+		names = append(names, strings.TrimSpace(category.Name))
+	}
+	tags, err := resolveTags(tx, names)
+	if err != nil {
+		return 0, err
+	}
+	article.Tags = tags
+
+	if err := tx.Create(article).Error; err != nil {
+		return 0, err
+	}
+	for _, tag := range tags {
+		im.tags[tag.Slug] = true
+	}
+	if err := im.record(tx, models.ImportKindArticle, item.PostID, article.ID); err != nil {
+		return 0, err
+	}
+
+	im.report.ArticlesCreated++
+	if status == models.ArticleStatusDraft {
+		im.report.ArticlesDraft++
+	}
+	return article.ID, nil
+}
+
+// importRedirects 保存文章的固定链接和guid，只保留与站点同域名的地址
+func (im *wpImporter) importRedirects(tx *gorm.DB, item *wxrItem, articleID uint) error {
+	for _, link := range []string{item.Link, item.GUID} {
+		u, err := url.Parse(strings.TrimSpace(link))
+		if err != nil || link == "" || (u.Host != "" && u.Host != im.host) {
+			continue
+		}
+		path := utils.RedirectPath(u)
+		if path == "/" {
+			continue
+		}
+
+		result := tx.Where("path = ?", path).FirstOrCreate(&models.ArticleRedirect{ArticleID: articleID, Path: path})
+		if result.Error != nil {
+			return result.Error
+		}
+		im.report.Redirects += int(result.RowsAffected)
+	}
+	return nil
+}
+
+// importComments 导入文章的评论，跳过垃圾评论、回收站中的评论和pingback
+func (im *wpImporter) importComments(tx *gorm.DB, item *wxrItem, articleID uint) error {
+	comments := item.Comments
+	// 按ID排序保证父评论先于回复导入
+	sort.SliceStable(comments, func(i, j int) bool {
+		a, _ := strconv.Atoi(comments[i].ID)
+		b, _ := strconv.Atoi(comments[j].ID)
+		return a < b
+	})
+
+	for _, comment := range comments {
+		if comment.Type == "pingback" || comment.Type == "trackback" {
+			im.report.Ignored["comment:"+comment.Type]++
+			continue
+		}
+
+		status := ""
+		switch comment.Approved {
+		case "1":
+			status = models.CommentStatusApproved
+		case "0":
+			status = models.CommentStatusPending
+		default:
+			im.report.Ignored["comment:"+comment.Approved]++
+			continue
+		}
+
+		if _, ok, err := im.lookup(tx, models.ImportKindComment, comment.ID); err != nil {
+			return err
+		} else if ok {
+			im.report.CommentsSkipped++
+			continue
+		}
+
+		content, err := utils.RenderContent(models.ContentFormatHTML, wpautop(comment.Content))
+		if err != nil {
+			return err
+		}
+
+		record := &models.Comment{
+			ArticleID:   articleID,
+			AuthorName:  comment.Author,
+			AuthorEmail: comment.Email,
+			AuthorURL:   comment.URL,
+			Content:     content,
+			Status:      status,
+		}
+		record.CreatedAt = wxrTime(comment.DateGMT, comment.Date)
+		record.UpdatedAt = record.CreatedAt
+
+		if comment.Parent != "" && comment.Parent != "0" {
+			if parentID, ok, err := im.lookup(tx, models.ImportKindComment, comment.Parent); err != nil {
+				return err
+			} else if ok {
+				record.ParentID = &parentID
+			}
+		}
+		if userID, ok := im.userIDs[comment.UserID]; ok && comment.UserID != "0" {
+			record.UserID = &userID
+		}
+
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		if err := im.record(tx, models.ImportKindComment, comment.ID, record.ID); err != nil {
+			return err
+		}
+
+		im.report.CommentsCreated++
+		if status == models.CommentStatusPending {
+			im.report.CommentsPending++
+		}
+	}
+	return nil
+}
+
+// wxrTime 解析WXR时间，优先使用GMT时间，没有时按本地时间解析站点时间
+func wxrTime(gmt, local string) time.Time {
+	if gmt != "" && gmt != wxrNoDate {
+		if t, err := time.ParseInLocation(wxrDateLayout, gmt, time.UTC); err == nil {
+			return t
+		}
+	}
+	if local != "" && local != wxrNoDate {
+		if t, err := time.ParseInLocation(wxrDateLayout, local, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+var (
+	wpParagraph  = regexp.MustCompile(`(?i)<p[\s>]`)
+	wpBlankLines = regexp.MustCompile(`\n\s*\n`)
+	wpBlockTag   = regexp.MustCompile(`(?i)^<(div|h[1-6]|ul|ol|li|dl|blockquote|pre|table|figure|hr|address|section|!--)`)
+)
+
+// wpautop 按WordPress的规则把空行分隔的文本转换为段落，已包含段落标签的内容（如块编辑器）保持不变
+func wpautop(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if wpParagraph.MatchString(content) {
+		return content
+	}
+
+	var sb strings.Builder
+	for _, block := range wpBlankLines.Split(strings.TrimSpace(content), -1) {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		if wpBlockTag.MatchString(block) {
+			sb.WriteString(block + "\n")
+			continue
+		}
+		sb.WriteString("<p>" + strings.ReplaceAll(block, "\n", "<br>\n") + "</p>\n")
+	}
+	return sb.String()
+}
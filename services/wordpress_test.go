@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/models"
+)
+
+// importWXR 导入 testdata/wordpress.xml
+func importWXR(t *testing.T, dryRun bool) *WordPressReport {
+	t.Helper()
+	f, err := os.Open("testdata/wordpress.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	report, err := ImportWordPress(f, WordPressOptions{DryRun: dryRun, DefaultAuthor: "alice"})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	return report
+}
+
+// countRows 统计表中的记录数，包括软删除的记录
+func countRows(t *testing.T, model interface{}) int64 {
+	t.Helper()
+	var count int64
+	database.GetDB().Unscoped().Model(model).Count(&count)
+	return count
+}
+
+func TestImportWordPress(t *testing.T) {
+	setupDB(t)
+	alice := createUser(t, "alice")
+	createArticle(t, alice.ID, "taken")
+	db := database.GetDB()
+
+	want := WordPressReport{
+		Source:          "old.example.com",
+		Items:           6,
+		UsersCreated:    1,
+		UsersExisting:   1,
+		ArticlesCreated: 3,
+		ArticlesDraft:   1,
+		CommentsCreated: 2,
+		CommentsPending: 1,
+		Tags:            2,
+		Redirects:       4,
+	}
+	wantIgnored := map[string]int{"post:trash": 1, "page": 1, "attachment": 1, "comment:spam": 1, "comment:pingback": 1}
+	checkReport := func(t *testing.T, report *WordPressReport, want WordPressReport) {
+		t.Helper()
+		got := *report
+		got.Ignored, got.Warnings = nil, nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("导入报告\n%+v\n期望\n%+v", got, want)
+		}
+		for kind, count := range wantIgnored {
+			if report.Ignored[kind] != count {
+				t.Errorf("未导入的 %s 为 %d，期望 %d", kind, report.Ignored[kind], count)
+			}
+		}
+	}
+
+	// 试运行的报告与实际导入一致，但不写入数据库
+	articles, users := countRows(t, &models.Article{}), countRows(t, &models.User{})
+	checkReport(t, importWXR(t, true), want)
+	if countRows(t, &models.Article{}) != articles || countRows(t, &models.User{}) != users ||
+		countRows(t, &models.Comment{}) != 0 || countRows(t, &models.ImportedItem{}) != 0 {
+		t.Fatalf("试运行写入了数据库")
+	}
+
+	report := importWXR(t, false)
+	checkReport(t, report, want)
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "taken") {
+		t.Errorf("警告 %v，期望slug被占用的警告", report.Warnings)
+	}
+
+	var wpauthor models.User
+	if err := db.Where("username = ?", "wpauthor").First(&wpauthor).Error; err != nil {
+		t.Fatalf("未创建作者: %v", err)
+	}
+	if wpauthor.DisplayName != "WP Author" || wpauthor.Email != "wp@example.com" {
+		t.Errorf("导入的作者 %+v", wpauthor)
+	}
+
+	t.Run("文章", func(t *testing.T) {
+		hello, err := GetArticleBySlug("hello-world")
+		if err != nil {
+			t.Fatalf("未导入文章: %v", err)
+		}
+		if hello.UserID != wpauthor.ID || hello.Status != models.ArticleStatusPublished || hello.Excerpt != "摘要" {
+			t.Errorf("导入的文章 %+v", hello)
+		}
+		// 空行分隔的文本转换为段落，保留GMT发布和修改时间
+		if hello.Content != "<p>第一段<br>\n第一段第二行</p>\n<p>第二段</p>\n" {
+			t.Errorf("文章内容 %q", hello.Content)
+		}
+		if !hello.CreatedAt.Equal(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)) ||
+			!hello.UpdatedAt.Equal(time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("文章时间 %v %v", hello.CreatedAt, hello.UpdatedAt)
+		}
+		// 默认分类不作为标签导入
+		if tags := tagSlugs(loadArticle(t, hello.ID)); !slices.Equal(tags, []string{"news", "go"}) {
+			t.Errorf("标签 %v，期望 [news go]", tags)
+		}
+
+		var drafts []models.Article
+		db.Where("status = ?", models.ArticleStatusDraft).Find(&drafts)
+		if len(drafts) != 1 || drafts[0].Slug != "draft-post" || drafts[0].UserID != alice.ID {
+			t.Fatalf("草稿 %+v，期望slug为draft-post、作者为默认作者", drafts)
+		}
+
+		for path, slug := range map[string]string{
+			"/2024/01/hello-world": "hello-world",
+			"/?p=10":               "hello-world",
+			"/taken":               "taken-2",
+		} {
+			if got, err := GetArticleRedirect(path); err != nil || got != slug {
+				t.Errorf("旧地址 %s 跳转到 %q，错误 %v，期望 %s", path, got, err, slug)
+			}
+		}
+		// 草稿的旧地址发布后才跳转
+		if _, err := GetArticleRedirect("/?p=11"); !errors.Is(err, ErrArticleNotFound) {
+			t.Errorf("草稿的旧地址错误 %v，期望 ErrArticleNotFound", err)
+		}
+		db.Model(&drafts[0]).UpdateColumn("status", models.ArticleStatusPublished)
+		if got, err := GetArticleRedirect("/?p=11"); err != nil || got != "draft-post" {
+			t.Errorf("发布后旧地址跳转到 %q，错误 %v，期望 draft-post", got, err)
+		}
+	})
+
+	t.Run("评论", func(t *testing.T) {
+		var comments []models.Comment
+		db.Order("id asc").Find(&comments)
+		if len(comments) != 2 {
+			t.Fatalf("导入了 %d 条评论，期望2条", len(comments))
+		}
+		parent, reply := comments[0], comments[1]
+		if parent.Status != models.CommentStatusApproved || parent.UserID == nil || *parent.UserID != wpauthor.ID ||
+			!strings.Contains(parent.Content, "作者的评论") {
+			t.Errorf("评论 %+v", parent)
+		}
+		if reply.Status != models.CommentStatusPending || reply.UserID != nil || reply.ParentID == nil || *reply.ParentID != parent.ID {
+			t.Errorf("回复 %+v，期望待审核、父评论为 %d", reply, parent.ID)
+		}
+	})
+
+	t.Run("重复导入跳过已导入的内容", func(t *testing.T) {
+		articles, comments := countRows(t, &models.Article{}), countRows(t, &models.Comment{})
+		checkReport(t, importWXR(t, false), WordPressReport{
+			Source:          "old.example.com",
+			Items:           6,
+			UsersExisting:   2,
+			ArticlesSkipped: 3,
+			CommentsSkipped: 2,
+		})
+		if countRows(t, &models.Article{}) != articles || countRows(t, &models.Comment{}) != comments {
+			t.Errorf("重复导入创建了新的文章或评论")
+		}
+	})
+}
+
+func TestWpautop(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"空行分隔段落", "一\r\n\r\n二", "<p>一</p>\n<p>二</p>\n"},
+		{"单个换行", "一\n二", "<p>一<br>\n二</p>\n"},
+		{"块级元素不包裹", "<h2>标题</h2>\n\n正文", "<h2>标题</h2>\n<p>正文</p>\n"},
+		{"已有段落标签", "<p>一</p>\n\n<p>二</p>", "<p>一</p>\n\n<p>二</p>"},
+		{"块编辑器注释", "<!-- wp:paragraph -->\n\n文字", "<!-- wp:paragraph -->\n<p>文字</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wpautop(tt.content); got != tt.want {
+				t.Errorf("wpautop(%q) = %q，期望 %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/dingdinglz/test-blog/config"
+)
+
+// ErrNotExist 文件不存在
+var ErrNotExist = errors.New("文件不存在")
+
+// Storage 上传文件的存储后端，key为使用 / 分隔的相对路径
+type Storage interface {
+	// Put 保存文件，size为-1时表示长度未知
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取文件，文件不存在时返回 ErrNotExist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// URL 文件的公开访问地址
+	URL(key string) string
+}
+
+var current Storage
+
+// Init 根据配置初始化存储后端
+func Init() error {
+	cfg := config.AppConfig.Upload
+
+	var err error
+	switch cfg.Storage {
+	case "local", "":
+		current, err = NewLocal(cfg.Local.Dir, cfg.Local.URLPrefix)
+	case "s3":
+		current, err = NewS3(cfg.S3)
+	default:
+		return fmt.Errorf("未知的存储类型: %s", cfg.Storage)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("文件存储初始化成功: %s\n", cfg.Storage)
+	return nil
+}
+
+// GetStorage 获取存储后端实例
+func GetStorage() Storage {
+	return current
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag 根据响应内容生成强ETag
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// VersionETag 生成以数据版本号开头的强ETag，如 "3-1f2e..."，既区分不同的响应内容，又可以用于 If-Match 并发控制
+func VersionETag(version uint, body []byte) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + "-" + strings.Trim(ETag(body), `"`) + `"`
+}
+
+// ParseIfMatch 解析 If-Match 请求头，只支持单个ETag或 *，ETag可以是 VersionETag 或只有版本号的 "3"；
+// 响应压缩后ETag会变为弱ETag，只比较版本号，因此同样接受；
+// 为 * 时any为true，无法解析时（包括多个ETag）ok为false，按不匹配处理
+func ParseIfMatch(header string) (version uint, any bool, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, true, true
+	}
+	header = strings.TrimPrefix(header, "W/")
+	if len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false, false
+	}
+	value, _, _ := strings.Cut(header[1:len(header)-1], "-")
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || v == 0 {
+		return 0, false, false
+	}
+	return uint(v), false, true
+}
+
+// NotModified 设置ETag和Last-Modified响应头，条件请求命中时写入304并返回true
+func NotModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-None-Match 优先于 If-Modified-Since
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if etag != "" && etagMatch(inm, etag) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+
+	if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.Truncate(time.Second).After(t) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// etagMatch If-None-Match 使用弱比较
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
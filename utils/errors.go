@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorCode 稳定的机器可读错误码
+type ErrorCode string
+
+// 通用错误码，各业务模块的错误码也统一在此声明，便于集中映射HTTP状态码
+const (
+	CodeInvalidParams      ErrorCode = "INVALID_PARAMS"
+	CodeInvalidFields      ErrorCode = "INVALID_FIELDS"
+	CodeInvalidID          ErrorCode = "INVALID_ID"
+	CodeUnauthorized       ErrorCode = "UNAUTHORIZED"
+	CodeTokenMissing       ErrorCode = "TOKEN_MISSING"
+	CodeTokenMalformed     ErrorCode = "TOKEN_MALFORMED"
+	CodeTokenInvalid       ErrorCode = "TOKEN_INVALID"
+	CodeInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
+	CodeForbidden          ErrorCode = "FORBIDDEN"
+	CodeNotFound           ErrorCode = "NOT_FOUND"
+	CodePreconditionNeeded ErrorCode = "PRECONDITION_REQUIRED"
+	CodeInvalidPatch       ErrorCode = "INVALID_PATCH"
+	CodePatchTestFailed    ErrorCode = "PATCH_TEST_FAILED"
+	CodeUnsupportedMedia   ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	CodeIdempotencyReused  ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyBusy    ErrorCode = "IDEMPOTENCY_IN_PROGRESS"
+	CodeInternal           ErrorCode = "INTERNAL_ERROR"
+
+	CodeUserNotFound  ErrorCode = "USER_NOT_FOUND"
+	CodeUsernameTaken ErrorCode = "USERNAME_TAKEN"
+	CodeEmailTaken    ErrorCode = "EMAIL_TAKEN"
+	CodeInvalidURL    ErrorCode = "INVALID_URL"
+	CodeInvalidAvatar ErrorCode = "INVALID_AVATAR"
+	CodeWrongPassword ErrorCode = "WRONG_PASSWORD"
+
+	CodeArticleNotFound  ErrorCode = "ARTICLE_NOT_FOUND"
+	CodeArticleForbidden ErrorCode = "ARTICLE_FORBIDDEN"
+	CodeInvalidSlug      ErrorCode = "INVALID_SLUG"
+	CodeSlugTaken        ErrorCode = "SLUG_TAKEN"
+	CodeInvalidCover     ErrorCode = "INVALID_COVER_IMAGE"
+	CodeNotInTrash       ErrorCode = "ARTICLE_NOT_IN_TRASH"
+	CodeVersionConflict  ErrorCode = "VERSION_CONFLICT"
+
+	CodeTagNotFound ErrorCode = "TAG_NOT_FOUND"
+	CodeInvalidTag  ErrorCode = "INVALID_TAG"
+
+	CodeMediaNotFound       ErrorCode = "MEDIA_NOT_FOUND"
+	CodeMediaForbidden      ErrorCode = "MEDIA_FORBIDDEN"
+	CodeMediaInUse          ErrorCode = "MEDIA_IN_USE"
+	CodeFileTooLarge        ErrorCode = "FILE_TOO_LARGE"
+	CodeUnsupportedFileType ErrorCode = "UNSUPPORTED_FILE_TYPE"
+	CodeImageTooLarge       ErrorCode = "IMAGE_TOO_LARGE"
+)
+
+// codeStatus 错误码到HTTP状态码的集中映射，未登记的错误码按500处理
+var codeStatus = map[ErrorCode]int{
+	CodeInvalidParams:      http.StatusBadRequest,
+	CodeInvalidFields:      http.StatusBadRequest,
+	CodeInvalidID:          http.StatusBadRequest,
+	CodeUnauthorized:       http.StatusUnauthorized,
+	CodeTokenMissing:       http.StatusUnauthorized,
+	CodeTokenMalformed:     http.StatusUnauthorized,
+	CodeTokenInvalid:       http.StatusUnauthorized,
+	CodeInvalidCredentials: http.StatusUnauthorized,
+	CodeForbidden:          http.StatusForbidden,
+	CodeNotFound:           http.StatusNotFound,
+	CodePreconditionNeeded: http.StatusPreconditionRequired,
+	CodeInvalidPatch:       http.StatusBadRequest,
+	CodePatchTestFailed:    http.StatusConflict,
+	CodeUnsupportedMedia:   http.StatusUnsupportedMediaType,
+	CodeIdempotencyReused:  http.StatusUnprocessableEntity,
+	CodeIdempotencyBusy:    http.StatusConflict,
+	CodeInternal:           http.StatusInternalServerError,
+
+	CodeUserNotFound:  http.StatusNotFound,
+	CodeUsernameTaken: http.StatusConflict,
+	CodeEmailTaken:    http.StatusConflict,
+	CodeInvalidURL:    http.StatusBadRequest,
+	CodeInvalidAvatar: http.StatusBadRequest,
+	CodeWrongPassword: http.StatusForbidden,
+
+	CodeArticleNotFound:  http.StatusNotFound,
+	CodeArticleForbidden: http.StatusForbidden,
+	CodeInvalidSlug:      http.StatusBadRequest,
+	CodeSlugTaken:        http.StatusConflict,
+	CodeInvalidCover:     http.StatusBadRequest,
+	CodeNotInTrash:       http.StatusConflict,
+	CodeVersionConflict:  http.StatusPreconditionFailed,
+
+	CodeTagNotFound: http.StatusNotFound,
+	CodeInvalidTag:  http.StatusBadRequest,
+
+	CodeMediaNotFound:       http.StatusNotFound,
+	CodeMediaForbidden:      http.StatusForbidden,
+	CodeMediaInUse:          http.StatusConflict,
+	CodeFileTooLarge:        http.StatusRequestEntityTooLarge,
+	CodeUnsupportedFileType: http.StatusUnsupportedMediaType,
+	CodeImageTooLarge:       http.StatusRequestEntityTooLarge,
+}
+
+// StatusOf 获取错误码对应的HTTP状态码
+func StatusOf(code ErrorCode) int {
+	if status, ok := codeStatus[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// AppError 业务错误，Message为默认文本（优先使用语言包中以Code为键的翻译），Err为内部原因只记录日志
+type AppError struct {
+	Code    ErrorCode
+	Message string
+	Err     error
+}
+
+// NewError 创建业务错误
+func NewError(code ErrorCode, message string) *AppError {
+	return &AppError{Code: code, Message: message}
+}
+
+// Error 实现error接口，包含内部原因，仅用于日志
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+// Unwrap 返回内部原因
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is 错误码相同即视为同一错误，使 errors.Is 可以匹配包装后的哨兵错误
+func (e *AppError) Is(target error) bool {
+	var t *AppError
+	if errors.As(target, &t) {
+		return t.Code == e.Code
+	}
+	return false
+}
+
+// Wrap 返回附带内部原因的副本，不修改哨兵错误本身
+func (e *AppError) Wrap(err error) *AppError {
+	return &AppError{Code: e.Code, Message: e.Message, Err: err}
+}
+
+// 通用哨兵错误
+var (
+	ErrInvalidParams      = NewError(CodeInvalidParams, "参数错误")
+	ErrInvalidFields      = NewError(CodeInvalidFields, "fields或include中包含未知字段")
+	ErrInvalidID          = NewError(CodeInvalidID, "无效的ID")
+	ErrUnauthorized       = NewError(CodeUnauthorized, "未授权")
+	ErrTokenMissing       = NewError(CodeTokenMissing, "未提供认证token")
+	ErrTokenMalformed     = NewError(CodeTokenMalformed, "token格式错误")
+	ErrTokenInvalid       = NewError(CodeTokenInvalid, "token无效或已过期")
+	ErrInvalidCredentials = NewError(CodeInvalidCredentials, "用户名或密码错误")
+	ErrForbidden          = NewError(CodeForbidden, "禁止访问")
+	ErrNotFound           = NewError(CodeNotFound, "资源不存在")
+	ErrPreconditionNeeded = NewError(CodePreconditionNeeded, "缺少If-Match请求头或version字段")
+	ErrInvalidPatch       = NewError(CodeInvalidPatch, "补丁格式错误或路径不存在")
+	ErrPatchTestFailed    = NewError(CodePatchTestFailed, "补丁中的test操作不满足")
+	ErrUnsupportedMedia   = NewError(CodeUnsupportedMedia, "不支持的请求体类型")
+	ErrIdempotencyReused  = NewError(CodeIdempotencyReused, "幂等键已用于内容不同的请求")
+	ErrIdempotencyBusy    = NewError(CodeIdempotencyBusy, "使用相同幂等键的请求正在处理中")
+	ErrInternal           = NewError(CodeInternal, "服务器内部错误")
+)
+
+// AsAppError 将任意错误转换为业务错误，未知错误视为内部错误
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternal.Wrap(err)
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// errInvalidImage 图片结构无法解析
+var errInvalidImage = errors.New("无法解析的图片结构")
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks 可能包含拍摄信息的PNG数据块
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+// webpMetadataChunks WebP中的元数据块，以及VP8X块中表示存在该块的标志位
+var webpMetadataChunks = map[string]byte{"EXIF": 0x08, "XMP ": 0x04}
+
+// StripMetadata 无损移除JPEG、PNG和WebP中的EXIF、XMP等元数据，返回移除后的数据和EXIF中的方向（1-8，没有时为1）
+// 方向不为1时调用方需要按方向旋转图片后重新编码，否则移除元数据后图片显示方向会错误
+func StripMetadata(mimeType string, data []byte) ([]byte, int, error) {
+	switch mimeType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		stripped, err := stripPNG(data)
+		return stripped, 1, err
+	case "image/webp":
+		// WebP规范要求忽略EXIF中的方向，不需要旋转
+		stripped, err := stripWebP(data)
+		return stripped, 1, err
+	default:
+		return data, 1, nil
+	}
+}
+
+// stripJPEG 移除APP1（EXIF、XMP）和APP13（IPTC）段，保留ICC色彩配置等其它段
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, errInvalidImage
+	}
+
+	orientation := 1
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, 0, errInvalidImage
+		}
+		marker := data[pos+1]
+		// 图像数据开始，之后的内容原样保留
+		if marker == 0xDA {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 0, errInvalidImage
+		}
+
+		segment := data[pos+4 : end]
+		switch marker {
+		case 0xE1:
+			if bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+				if o := exifOrientation(segment[6:]); o > 0 {
+					orientation = o
+				}
+			}
+		case 0xED:
+		default:
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	out.Write(data[pos:])
+	return out.Bytes(), orientation, nil
+}
+
+// exifOrientation 读取TIFF结构IFD0中的Orientation标签
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// stripPNG 移除PNG中的文本和EXIF数据块
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errInvalidImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errInvalidImage
+		}
+		if !pngMetadataChunks[string(data[pos+4:pos+8])] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	return out.Bytes(), nil
+}
+
+// stripWebP 移除WebP扩展格式中的EXIF和XMP数据块，并清除VP8X块中对应的标志位
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	var flags byte
+	vp8x := -1
+	pos := 12
+	for pos+8 <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		// 数据块长度为奇数时补一个字节
+		end := pos + 8 + length + length%2
+		if length < 0 || end > len(data) {
+			// 部分编码器省略最后一个块的填充字节
+			if end == len(data)+1 {
+				end = len(data)
+			} else {
+				return nil, errInvalidImage
+			}
+		}
+		if flag, ok := webpMetadataChunks[fourCC]; ok {
+			flags |= flag
+		} else {
+			if fourCC == "VP8X" && length > 0 {
+				vp8x = out.Len() + 8
+			}
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	stripped := out.Bytes()
+	if vp8x >= 0 {
+		stripped[vp8x] &^= flags
+	}
+	binary.LittleEndian.PutUint32(stripped[4:8], uint32(len(stripped)-8))
+	return stripped, nil
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// riffChunk 构造WebP数据块，长度为奇数时补一个字节
+func riffChunk(fourCC string, payload []byte) []byte {
+	chunk := append([]byte(fourCC), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// webpFile 用数据块构造WebP文件
+func webpFile(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+	file := append([]byte("RIFF"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(file[4:], uint32(len(body)))
+	return append(file, body...)
+}
+
+// pngChunk 构造PNG数据块
+func pngChunk(typ string, payload []byte) []byte {
+	chunk := make([]byte, 4, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// jpegSegment 构造JPEG段
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// exifTIFF 只包含Orientation标签的小端TIFF结构
+func exifTIFF(orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], 0x0112)
+	binary.LittleEndian.PutUint16(entry[2:], 3)
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], orientation)
+	return append(append(tiff, entry...), 0, 0, 0, 0)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestStripMetadata(t *testing.T) {
+	// VP8X的标志位：ICC 0x20、EXIF 0x08、XMP 0x04
+	vp8x := func(flags byte) []byte {
+		return riffChunk("VP8X", []byte{flags, 0, 0, 0, 9, 0, 0, 9, 0, 0})
+	}
+	image := riffChunk("VP8L", []byte{0x2F, 1, 2, 3, 4})
+	icc := riffChunk("ICCP", []byte("profile"))
+	exif := riffChunk("EXIF", exifTIFF(6))
+	xmp := riffChunk("XMP ", []byte("<x:xmpmeta>GPS</x:xmpmeta>"))
+
+	pngIHDR := pngChunk("IHDR", make([]byte, 13))
+	pngIDAT := pngChunk("IDAT", []byte{1, 2, 3})
+	pngEnd := pngChunk("IEND", nil)
+
+	soi := []byte{0xFF, 0xD8}
+	scan := []byte{0xFF, 0xDA, 0, 2, 9, 9, 0xFF, 0xD9}
+	jfif := jpegSegment(0xE0, []byte("JFIF\x00"))
+	jpegICC := jpegSegment(0xE2, []byte("ICC_PROFILE\x00"))
+
+	tests := []struct {
+		name            string
+		mimeType        string
+		data            []byte
+		want            []byte
+		wantOrientation int
+		wantErr         bool
+	}{
+		{
+			name:            "WebP移除EXIF和XMP并清除标志位",
+			mimeType:        "image/webp",
+			data:            webpFile(vp8x(0x2C), icc, image, exif, xmp),
+			want:            webpFile(vp8x(0x20), icc, image),
+			wantOrientation: 1,
+		},
+		{
+			name:            "WebP简单格式不变",
+			mimeType:        "image/webp",
+			data:            webpFile(image),
+			want:            webpFile(image),
+			wantOrientation: 1,
+		},
+		{
+			name:     "WebP头部错误",
+			mimeType: "image/webp",
+			data:     []byte("RIFF\x04\x00\x00\x00WAVE"),
+			wantErr:  true,
+		},
+		{
+			name:     "WebP数据块长度越界",
+			mimeType: "image/webp",
+			data:     concat(webpFile(image), []byte("EXIF\xff\x00\x00\x00")),
+			wantErr:  true,
+		},
+		{
+			name:            "PNG移除文本和EXIF块",
+			mimeType:        "image/png",
+			data:            concat(pngSignature, pngIHDR, pngChunk("tEXt", []byte("GPS\x00here")), pngChunk("eXIf", exifTIFF(1)), pngIDAT, pngEnd),
+			want:            concat(pngSignature, pngIHDR, pngIDAT, pngEnd),
+			wantOrientation: 1,
+		},
+		{
+			name:            "JPEG移除EXIF并读取方向，保留ICC",
+			mimeType:        "image/jpeg",
+			data:            concat(soi, jfif, jpegSegment(0xE1, concat([]byte("Exif\x00\x00"), exifTIFF(6))), jpegICC, jpegSegment(0xED, []byte("IPTC")), scan),
+			want:            concat(soi, jfif, jpegICC, scan),
+			wantOrientation: 6,
+		},
+		{
+			name:     "JPEG缺少SOI",
+			mimeType: "image/jpeg",
+			data:     scan,
+			wantErr:  true,
+		},
+		{
+			name:            "其他类型不变",
+			mimeType:        "image/gif",
+			data:            []byte("GIF89a"),
+			want:            []byte("GIF89a"),
+			wantOrientation: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, orientation, err := StripMetadata(tt.mimeType, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 %v，期望返回错误 %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("结果\n%q\n期望\n%q", got, tt.want)
+			}
+			if orientation != tt.wantOrientation {
+				t.Errorf("方向 %d，期望 %d", orientation, tt.wantOrientation)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldSet 响应中保留的字段，键为JSON字段路径，如 title、author.username
+type FieldSet map[string]bool
+
+// SplitList 拆分逗号分隔的查询参数，去掉空白和空项
+func SplitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseFields 解析逗号分隔的字段列表，allowed为可选的字段路径，包含未知字段时返回错误
+func ParseFields(raw string, allowed map[string]bool) (FieldSet, error) {
+	fields := FieldSet{}
+	for _, field := range SplitList(raw) {
+		if !allowed[field] {
+			return nil, fmt.Errorf("未知字段: %s", field)
+		}
+		fields[field] = true
+	}
+	return fields, nil
+}
+
+// JSONPaths 列出结构体的JSON字段路径，结构体、结构体指针和结构体切片类型的字段展开一层
+func JSONPaths(v interface{}) map[string]bool {
+	paths := map[string]bool{}
+	collectPaths(reflect.TypeOf(v), "", paths, 2)
+	return paths
+}
+
+// collectPaths 递归收集字段路径，depth为剩余展开层数
+func collectPaths(t reflect.Type, prefix string, paths map[string]bool, depth int) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || depth == 0 {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		paths[prefix+name] = true
+		collectPaths(field.Type, prefix+name+".", paths, depth-1)
+	}
+}
+
+// SelectFields 把v转换为只包含fields中字段的JSON值，选择父字段时保留其全部子字段，字段顺序与v相同
+func SelectFields(v interface{}, fields FieldSet) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	return selectValue(value, fields, ""), nil
+}
+
+// jsonObject 保持字段顺序的JSON对象
+type jsonObject []jsonMember
+
+// jsonMember jsonObject中的字段
+type jsonMember struct {
+	Key   string
+	Value interface{}
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(member.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(member.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered 解码JSON值，对象解码为jsonObject以保留字段顺序
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		object := jsonObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, jsonMember{Key: key.(string), Value: value})
+		}
+		_, err = dec.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = dec.Token()
+		return array, err
+	default:
+		return tok, nil
+	}
+}
+
+// selectValue 按前缀筛选对象中的字段，数组对每个元素使用相同的前缀
+func selectValue(value interface{}, fields FieldSet, prefix string) interface{} {
+	switch v := value.(type) {
+	case jsonObject:
+		selected := jsonObject{}
+		for _, member := range v {
+			path := prefix + member.Key
+			switch {
+			case fields[path]:
+				selected = append(selected, member)
+			case hasChildField(fields, path):
+				selected = append(selected, jsonMember{Key: member.Key, Value: selectValue(member.Value, fields, path+".")})
+			}
+		}
+		return selected
+	case []interface{}:
+		for i := range v {
+			v[i] = selectValue(v[i], fields, prefix)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// hasChildField 判断是否选择了path下的子字段
+func hasChildField(fields FieldSet, path string) bool {
+	for field := range fields {
+		if strings.HasPrefix(field, path+".") {
+			return true
+		}
+	}
+	return false
+}
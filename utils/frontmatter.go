@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// frontmatterDelimiter YAML frontmatter分隔符，Hugo和Jekyll通用
+const frontmatterDelimiter = "---"
+
+// StringList 兼容单个字符串和字符串列表的YAML字段
+type StringList []string
+
+// UnmarshalYAML 实现 yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			*l = nil
+			return nil
+		}
+		*l = StringList{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	default:
+		return fmt.Errorf("第 %d 行: 应为字符串或字符串列表", node.Line)
+	}
+}
+
+// FirstString 兼容单个字符串和字符串列表的YAML字段，列表时取第一个
+type FirstString string
+
+// UnmarshalYAML 实现 yaml.Unmarshaler
+func (s *FirstString) UnmarshalYAML(node *yaml.Node) error {
+	var list StringList
+	if err := list.UnmarshalYAML(node); err != nil {
+		return err
+	}
+	*s = ""
+	if len(list) > 0 {
+		*s = FirstString(strings.TrimSpace(list[0]))
+	}
+	return nil
+}
+
+// Frontmatter Markdown文件头部的元数据，同时识别Hugo和Jekyll的常用字段
+type Frontmatter struct {
+	Title      string      `yaml:"title"`
+	Slug       string      `yaml:"slug,omitempty"`
+	Author     FirstString `yaml:"author,omitempty"`
+	Authors    StringList  `yaml:"authors,omitempty"` // Hugo
+	Date       string      `yaml:"date,omitempty"`
+	LastMod    string      `yaml:"lastmod,omitempty"` // Hugo
+	Updated    string      `yaml:"updated,omitempty"`
+	Tags       StringList  `yaml:"tags,omitempty"`
+	Categories StringList  `yaml:"categories,omitempty"`
+	Excerpt    string      `yaml:"excerpt,omitempty"` // Jekyll
+	Summary    string      `yaml:"summary,omitempty"` // Hugo
+	Image      FirstString `yaml:"image,omitempty"`   // Jekyll
+	Images     StringList  `yaml:"images,omitempty"`  // Hugo
+	Status     string      `yaml:"status,omitempty"`
+	Draft      bool        `yaml:"draft,omitempty"`     // Hugo
+	Published  *bool       `yaml:"published,omitempty"` // Jekyll
+	Format     string      `yaml:"format,omitempty"`
+}
+
+// AuthorName 文章作者，多个作者时取第一个
+func (f *Frontmatter) AuthorName() string {
+	if f.Author != "" {
+		return string(f.Author)
+	}
+	if len(f.Authors) > 0 {
+		return strings.TrimSpace(f.Authors[0])
+	}
+	return ""
+}
+
+// ExcerptText 文章摘要，兼容Jekyll的excerpt和Hugo的summary
+func (f *Frontmatter) ExcerptText() string {
+	if f.Excerpt != "" {
+		return strings.TrimSpace(f.Excerpt)
+	}
+	return strings.TrimSpace(f.Summary)
+}
+
+// CoverImage 封面图片，兼容Jekyll的image和Hugo的images（取第一张）
+func (f *Frontmatter) CoverImage() string {
+	if f.Image != "" {
+		return string(f.Image)
+	}
+	if len(f.Images) > 0 {
+		return strings.TrimSpace(f.Images[0])
+	}
+	return ""
+}
+
+// ParseFrontmatter 拆分文件头部的YAML frontmatter和正文，没有frontmatter时返回空元数据
+func ParseFrontmatter(data []byte) (*Frontmatter, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	meta := &Frontmatter{}
+	if !strings.HasPrefix(text, frontmatterDelimiter+"\n") {
+		return meta, text, nil
+	}
+
+	rest := text[len(frontmatterDelimiter)+1:]
+	var header, body string
+	if strings.HasPrefix(rest, frontmatterDelimiter+"\n") || rest == frontmatterDelimiter {
+		body = strings.TrimPrefix(rest, frontmatterDelimiter)
+	} else {
+		end := strings.Index(rest, "\n"+frontmatterDelimiter+"\n")
+		if end < 0 {
+			if !strings.HasSuffix(rest, "\n"+frontmatterDelimiter) {
+				return nil, "", errors.New("frontmatter缺少结束分隔符")
+			}
+			end = len(rest) - len(frontmatterDelimiter) - 1
+		}
+		header = rest[:end]
+		body = rest[min(end+len(frontmatterDelimiter)+2, len(rest)):]
+	}
+
+	if err := yaml.Unmarshal([]byte(header), meta); err != nil {
+		return nil, "", fmt.Errorf("解析frontmatter失败: %w", err)
+	}
+
+	return meta, strings.TrimLeft(body, "\n"), nil
+}
+
+// FormatFrontmatter 生成带YAML frontmatter的文件内容
+func FormatFrontmatter(meta *Frontmatter, body string) ([]byte, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontmatterDelimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(frontmatterDelimiter + "\n\n")
+	buf.WriteString(body)
+	if !strings.HasSuffix(body, "\n") {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// dateLayouts frontmatter中常见的日期格式
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700", // Jekyll
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseDate 解析frontmatter中的日期，不带时区的日期按本地时间处理
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期格式: %s", value)
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bytes"
+	htmlstd "html"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// markdown Markdown渲染器，支持GFM（表格、删除线、任务列表、自动链接）、脚注和代码高亮
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.TaskList,
+		extension.Footnote,
+		highlighting.NewHighlighting(
+			// 使用CSS类而不是内联样式，主题样式由前端提供
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		),
+	),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	// 保留原始HTML，统一交给sanitizer清洗
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// sanitizer HTML清洗策略，在UGC策略基础上放行渲染器生成的必要属性
+var sanitizer = newSanitizer()
+
+func newSanitizer() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// 代码高亮和脚注使用的class
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9\-_ ]+$`)).
+		OnElements("pre", "code", "span", "div", "a", "sup", "li", "ol", "section", "hr")
+
+	// 表格对齐
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|right|center)$`)).OnElements("th", "td")
+
+	// 任务列表复选框
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+
+	// 标题锚点
+	p.AllowAttrs("id").OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+
+	return p
+}
+
+// RenderContent 按内容格式渲染为安全的HTML
+func RenderContent(format, content string) (string, error) {
+	switch format {
+	case models.ContentFormatHTML:
+		return sanitizer.Sanitize(content), nil
+	case models.ContentFormatPlain:
+		return renderPlain(content), nil
+	default:
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(content), &buf); err != nil {
+			return "", err
+		}
+		return sanitizer.Sanitize(buf.String()), nil
+	}
+}
+
+// renderPlain 纯文本按空行分段，段内换行转换为<br>
+func renderPlain(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var sb strings.Builder
+	for _, para := range strings.Split(content, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		sb.WriteString("<p>")
+		sb.WriteString(strings.ReplaceAll(htmlstd.EscapeString(para), "\n", "<br>"))
+		sb.WriteString("</p>\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,361 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 补丁文档的媒体类型
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// MergePatch 按 RFC 7396 把合并补丁应用到JSON文档：对象逐字段合并，null删除字段，其他值直接替换
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, ErrInvalidPatch.Wrap(err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+// mergeValue 递归合并补丁
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergeValue(t[key], value)
+	}
+	return t
+}
+
+// patchOperation JSON Patch中的一个操作，Value为nil表示未提供value
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch 按 RFC 6902 依次执行JSON Patch中的操作，任一操作失败时整个补丁不生效；
+// test操作不满足时返回 ErrPatchTestFailed
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, ErrInvalidPatch.Wrap(err)
+	}
+
+	for i, op := range ops {
+		var err error
+		if target, err = applyOperation(target, op); err != nil {
+			var appErr *AppError
+			if errors.As(err, &appErr) {
+				return nil, appErr
+			}
+			return nil, ErrInvalidPatch.Wrap(fmt.Errorf("第%d个操作: %w", i+1, err))
+		}
+	}
+	return json.Marshal(target)
+}
+
+// PatchAsserts 判断补丁是否为文档的顶层字段name给出了值：合并补丁中该字段存在且不为null，
+// JSON Patch中有对 /name 的test或replace操作；删除字段不算
+func PatchAsserts(patch []byte, contentType, name string) bool {
+	if contentType == JSONPatchType {
+		var ops []patchOperation
+		if json.Unmarshal(patch, &ops) != nil {
+			return false
+		}
+		for _, op := range ops {
+			if (op.Op == "test" || op.Op == "replace") && op.Path != nil && *op.Path == "/"+name &&
+				op.Value != nil && string(op.Value) != "null" {
+				return true
+			}
+		}
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(patch, &fields) != nil {
+		return false
+	}
+	value, ok := fields[name]
+	return ok && string(value) != "null"
+}
+
+// PatchTestValue 返回JSON Patch中对文档原有的顶层字段name的test操作的值：在修改该字段的操作之前的第一个test操作，
+// 之后的test比较的是补丁修改后的值；没有时ok为false
+func PatchTestValue(patch []byte, name string) (value json.RawMessage, ok bool) {
+	var ops []patchOperation
+	if json.Unmarshal(patch, &ops) != nil {
+		return nil, false
+	}
+	path := "/" + name
+	modifies := func(pointer string) bool {
+		return pointer == "" || pointer == path || strings.HasPrefix(pointer, path+"/")
+	}
+	for _, op := range ops {
+		if op.Path == nil {
+			return nil, false
+		}
+		if op.Op == "test" {
+			if *op.Path == path && op.Value != nil {
+				return op.Value, true
+			}
+			continue
+		}
+		if modifies(*op.Path) || (op.Op == "move" && op.From != nil && modifies(*op.From)) {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// applyOperation 执行单个操作，返回新的文档
+func applyOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("缺少path")
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%s操作缺少value", op.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			if doc, _, err = removeValue(doc, path); err != nil {
+				return nil, err
+			}
+			return addValue(doc, path, value)
+		default:
+			current, err := getValue(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrPatchTestFailed.Wrap(fmt.Errorf("%s的值不一致", *op.Path))
+			}
+			return doc, nil
+		}
+	case "remove":
+		doc, _, err = removeValue(doc, path)
+		return doc, err
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%s操作缺少from", op.Op)
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if op.Op == "move" {
+			if strings.HasPrefix(*op.Path, *op.From+"/") {
+				return nil, fmt.Errorf("不能把 %s 移动到自身的子节点", *op.From)
+			}
+			if doc, value, err = removeValue(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = getValue(doc, from); err != nil {
+				return nil, err
+			}
+			if value, err = copyValue(value); err != nil {
+				return nil, err
+			}
+		}
+		return addValue(doc, path, value)
+	default:
+		return nil, fmt.Errorf("未知操作: %q", op.Op)
+	}
+}
+
+// parsePointer 解析 RFC 6901 JSON Pointer，空字符串表示整个文档
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("无效的路径: %s", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// arrayIndex 解析数组下标，max为允许的最大值
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.Trim(token, "0123456789") != "" {
+		return 0, fmt.Errorf("无效的数组下标: %s", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > max {
+		return 0, fmt.Errorf("数组下标越界: %s", token)
+	}
+	return i, nil
+}
+
+// getValue 获取路径指向的值
+func getValue(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("字段不存在: %s", token)
+			}
+			node = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("路径不存在: %s", token)
+		}
+	}
+	return node, nil
+}
+
+// addValue 在路径处添加值：对象中添加或替换字段，数组中在下标前插入，- 表示追加到末尾
+func addValue(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("字段不存在: %s", token)
+		}
+		child, err := addValue(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []interface{}:
+		if len(path) == 1 {
+			if token == "-" {
+				return append(n, value), nil
+			}
+			i, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := addValue(n[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	default:
+		return nil, fmt.Errorf("路径不存在: %s", token)
+	}
+}
+
+// removeValue 删除路径处的值，返回新的文档和被删除的值
+func removeValue(node interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, node, nil
+	}
+
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("字段不存在: %s", token)
+		}
+		if len(path) == 1 {
+			delete(n, token)
+			return n, child, nil
+		}
+		child, removed, err := removeValue(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = child
+		return n, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		child, removed, err := removeValue(n[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = child
+		return n, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("路径不存在: %s", token)
+	}
+}
+
+// copyValue 深拷贝JSON值，避免copy操作后两处共享同一个对象
+func copyValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var copied interface{}
+	err = json.Unmarshal(data, &copied)
+	return copied, err
+}
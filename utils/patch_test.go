@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assertJSON 按JSON值比较，忽略字段顺序和空白
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("结果不是有效的JSON: %s", got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("期望值不是有效的JSON: %s", want)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("结果 %s，期望 %s", got, want)
+	}
+}
+
+// TestMergePatch RFC 7396 附录A的示例
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch 返回错误: %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	_, err := MergePatch([]byte(`{}`), []byte(`{`))
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("错误 %v，期望 ErrInvalidPatch", err)
+	}
+}
+
+// TestJSONPatch RFC 6902 附录A的示例，以及指针转义、数组下标和移动到子节点的情况
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{"添加对象成员", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"添加数组元素", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"删除对象成员", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"删除数组元素", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"替换值", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"移动值", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"移动数组元素", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{"test成功", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"test失败", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``, ErrPatchTestFailed},
+		{"添加嵌套成员", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{"忽略未知成员", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`, nil},
+		{"添加到不存在的目标", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``, ErrInvalidPatch},
+		{"指针转义", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`, nil},
+		{"指针转义不相等", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, ``, ErrPatchTestFailed},
+		{"斜杠转义", `{"a/b":1}`, `[{"op":"replace","path":"/a~1b","value":2}]`, `{"a/b":2}`, nil},
+		{"添加数组", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, nil},
+		{"追加到末尾下标", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/1","value":"baz"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"数组下标越界", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"baz"}]`, ``, ErrInvalidPatch},
+		{"数组下标前导零", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`, ``, ErrInvalidPatch},
+		{"数组下标非数字", `{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/a"}]`, ``, ErrInvalidPatch},
+		{"复制值", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`, `{"foo":{"bar":1},"baz":{"bar":2}}`, nil},
+		{"移动到子节点", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, ``, ErrInvalidPatch},
+		{"移动到前缀相同的兄弟节点", `{"a":1}`, `[{"op":"move","from":"/a","path":"/ab"}]`, `{"ab":1}`, nil},
+		{"替换整个文档", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`, nil},
+		{"缺少value", `{}`, `[{"op":"add","path":"/a"}]`, ``, ErrInvalidPatch},
+		{"缺少path", `{}`, `[{"op":"remove"}]`, ``, ErrInvalidPatch},
+		{"未知操作", `{}`, `[{"op":"merge","path":"/a","value":1}]`, ``, ErrInvalidPatch},
+		{"路径不以斜杠开头", `{"a":1}`, `[{"op":"remove","path":"a"}]`, ``, ErrInvalidPatch},
+		{"补丁不是数组", `{}`, `{"op":"add"}`, ``, ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("错误 %v，期望 %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONPatch 返回错误: %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestPatchAsserts(t *testing.T) {
+	tests := []struct {
+		name        string
+		patch       string
+		contentType string
+		want        bool
+	}{
+		{"合并补丁给出版本", `{"version":3}`, MergePatchType, true},
+		{"合并补丁版本为0", `{"version":0}`, MergePatchType, true},
+		{"合并补丁版本为null", `{"version":null}`, MergePatchType, false},
+		{"合并补丁不含版本", `{"title":"a"}`, MergePatchType, false},
+		{"合并补丁不是对象", `[1]`, MergePatchType, false},
+		{"JSON Patch test版本", `[{"op":"test","path":"/version","value":3}]`, JSONPatchType, true},
+		{"JSON Patch replace版本", `[{"op":"replace","path":"/version","value":3}]`, JSONPatchType, true},
+		{"JSON Patch test版本为null", `[{"op":"test","path":"/version","value":null}]`, JSONPatchType, false},
+		{"JSON Patch 删除版本", `[{"op":"remove","path":"/version"}]`, JSONPatchType, false},
+		{"JSON Patch add版本", `[{"op":"add","path":"/version","value":3}]`, JSONPatchType, false},
+		{"JSON Patch 其他字段", `[{"op":"test","path":"/title","value":"a"}]`, JSONPatchType, false},
+		{"JSON Patch 子路径", `[{"op":"test","path":"/version/0","value":3}]`, JSONPatchType, false},
+		{"JSON Patch 格式错误", `{`, JSONPatchType, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PatchAsserts([]byte(tt.patch), tt.contentType, "version"); got != tt.want {
+				t.Errorf("PatchAsserts = %t，期望 %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"/", []string{""}, false},
+		{"/foo/0", []string{"foo", "0"}, false},
+		{"/a~1b", []string{"a/b"}, false},
+		{"/m~0n", []string{"m~n"}, false},
+		{"/~01", []string{"~1"}, false},
+		{"foo", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := parsePointer(tt.pointer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 %v，期望返回错误 %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePointer = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrayIndex(t *testing.T) {
+	tests := []struct {
+		token   string
+		max     int
+		want    int
+		wantErr bool
+	}{
+		{"0", 0, 0, false},
+		{"2", 3, 2, false},
+		{"3", 3, 3, false},
+		{"4", 3, 0, true},
+		{"01", 3, 0, true},
+		{"-1", 3, 0, true},
+		{"-", 3, 0, true},
+		{"", 3, 0, true},
+		{"1a", 3, 0, true},
+		{"99999999999999999999", 3, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			got, err := arrayIndex(tt.token, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 %v，期望返回错误 %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("arrayIndex = %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchTestValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		patch  string
+		want   string
+		wantOK bool
+	}{
+		{"test版本", `[{"op":"test","path":"/version","value":3},{"op":"replace","path":"/title","value":"a"}]`, `3`, true},
+		{"其他操作之后的test", `[{"op":"replace","path":"/title","value":"a"},{"op":"test","path":"/version","value":3}]`, `3`, true},
+		{"修改版本之后的test比较修改后的值", `[{"op":"replace","path":"/version","value":5},{"op":"test","path":"/version","value":5}]`, ``, false},
+		{"移走版本之后的test", `[{"op":"move","from":"/version","path":"/v"},{"op":"test","path":"/version","value":3}]`, ``, false},
+		{"替换整个文档之后的test", `[{"op":"replace","path":"","value":{}},{"op":"test","path":"/version","value":3}]`, ``, false},
+		{"只有replace", `[{"op":"replace","path":"/version","value":3}]`, ``, false},
+		{"其他字段的test", `[{"op":"test","path":"/title","value":"a"}]`, ``, false},
+		{"格式错误", `{"version":3}`, ``, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PatchTestValue([]byte(tt.patch), "version")
+			if ok != tt.wantOK || string(got) != tt.want {
+				t.Errorf("PatchTestValue = %s, %t，期望 %s, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,33 +1,118 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dingdinglz/test-blog/i18n"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 // Response 统一响应结构
 type Response struct {
 	Code    int         `json:"code"`
+	Error   ErrorCode   `json:"error,omitempty"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
 }
 
-// Success 成功响应
+// 成功消息键，对应语言包中的文本
+const (
+	MsgSuccess    = "success"
+	MsgCreated    = "created"
+	MsgUpdated    = "updated"
+	MsgDeleted    = "deleted"
+	MsgRestored   = "restored"
+	MsgRegistered = "registered"
+	MsgLoggedIn   = "logged_in"
+)
+
+// locale 获取当前请求协商出的语言
+func locale(c *gin.Context) string {
+	if l := c.GetString(i18n.ContextKey); l != "" {
+		return l
+	}
+	return i18n.DefaultLocale()
+}
+
+// Success 成功响应，message为语言包中的消息键，找不到翻译时原样返回
 func Success(c *gin.Context, data interface{}, message string) {
 	if message == "" {
-		message = "success"
+		message = MsgSuccess
 	}
 	c.JSON(200, Response{
 		Code:    200,
-		Message: message,
+		Message: i18n.Message(locale(c), message, message),
+		Data:    data,
+	})
+}
+
+// SuccessCacheable 可缓存的成功响应，etag根据响应体生成ETag，lastModified不为零值时设置Last-Modified；
+// GET请求的 If-None-Match 或 If-Modified-Since 命中时返回304
+func SuccessCacheable(c *gin.Context, data interface{}, message string, etag func(body []byte) string, lastModified time.Time) {
+	if message == "" {
+		message = MsgSuccess
+	}
+	body, err := json.Marshal(Response{
+		Code:    200,
+		Message: i18n.Message(locale(c), message, message),
 		Data:    data,
 	})
+	if err != nil {
+		Error(c, ErrInternal.Wrap(err))
+		return
+	}
+
+	if c.Request.Method == http.MethodGet {
+		if NotModified(c, etag(body), lastModified) {
+			return
+		}
+	} else {
+		c.Header("ETag", etag(body))
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
 }
 
-// Error 错误响应
-func Error(c *gin.Context, code int, message string) {
-	c.JSON(code, Response{
-		Code:    code,
+// Error 错误响应，根据错误码确定HTTP状态码，内部原因只记录日志不返回给客户端
+func Error(c *gin.Context, err error) {
+	ErrorWithData(c, err, nil)
+}
+
+// ErrorWithData 附带数据的错误响应，如版本冲突时返回服务器上的最新数据
+func ErrorWithData(c *gin.Context, err error, data interface{}) {
+	appErr, message := ErrorInfo(c, err)
+	status := StatusOf(appErr.Code)
+
+	// 错误响应不缓存，覆盖路由配置的 Cache-Control
+	c.Header("Cache-Control", "no-store")
+
+	c.JSON(status, Response{
+		Code:    status,
+		Error:   appErr.Code,
 		Message: message,
-		Data:    nil,
+		Data:    data,
 	})
 }
+
+// ErrorInfo 获取错误对应的业务错误和翻译后的消息，也用于在响应数据中返回多个错误，如批量操作的逐项结果
+func ErrorInfo(c *gin.Context, err error) (*AppError, string) {
+	appErr := AsAppError(err)
+	lang := locale(c)
+
+	message := i18n.Message(lang, string(appErr.Code), appErr.Message)
+
+	// 参数校验错误属于客户端可见信息，翻译后附加在消息后
+	var validationErrs validator.ValidationErrors
+	if errors.As(appErr.Err, &validationErrs) {
+		message += ": " + strings.Join(i18n.TranslateValidation(lang, validationErrs), "; ")
+	} else if appErr.Err != nil || StatusOf(appErr.Code) >= 500 {
+		log.Printf("[%s] %s %s | %v\n", "ERROR", c.Request.Method, c.Request.URL.Path, err)
+	}
+
+	return appErr, message
+}
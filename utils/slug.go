@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// maxSlugLength slug最大长度
+const maxSlugLength = 80
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// pinyinArgs 不带声调的拼音，无拼音的字符由调用方处理
+var pinyinArgs = pinyin.NewArgs()
+
+// Slugify 将标题转换为URL友好的slug，中文转换为拼音，其它字符转为连字符
+func Slugify(title string) string {
+	var words []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(r)
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				words = append(words, py[0])
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	// 按单词截断，避免切断拼音
+	var sb strings.Builder
+	for _, w := range words {
+		if sb.Len() > 0 && sb.Len()+1+len(w) > maxSlugLength {
+			break
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteString(w)
+	}
+
+	slug := sb.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+	}
+	return slug
+}
+
+// ValidSlug 校验slug格式：小写字母、数字，以单个连字符分隔
+func ValidSlug(slug string) bool {
+	return len(slug) <= maxSlugLength && slugPattern.MatchString(slug)
+}
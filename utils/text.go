@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"html"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+var stripPolicy = bluemonday.StrictPolicy()
+
+// PlainText 去除HTML标签并合并空白
+func PlainText(contentHTML string) string {
+	text := html.UnescapeString(stripPolicy.Sanitize(contentHTML))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Summarize 生成不超过length个字符的纯文本摘要
+func Summarize(contentHTML string, length int) string {
+	runes := []rune(PlainText(contentHTML))
+	if length <= 0 || len(runes) <= length {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:length])) + "…"
+}
+
+// 阅读速度，中日韩文字按字计算，其它文字按词计算
+const (
+	wordsPerMinute    = 200
+	cjkCharsPerMinute = 300
+)
+
+// isCJK 判断是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// CountWords 统计纯文本的词数，中日韩文字每个字计为一个词，其它文字以连续的字母和数字计为一个词
+func CountWords(text string) (words, cjk int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case (r == '\'' || r == '’') && inWord:
+			// don't、it’s 等缩写不拆分
+		default:
+			inWord = false
+		}
+	}
+	return words, cjk
+}
+
+// ReadingTime 根据词数估算阅读分钟数，不足1分钟按1分钟计算
+func ReadingTime(words, cjk int) int {
+	minutes := math.Ceil(float64(words)/wordsPerMinute + float64(cjk)/cjkCharsPerMinute)
+	return max(int(minutes), 1)
+}
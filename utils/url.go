@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/dingdinglz/test-blog/config"
+)
+
+// AbsoluteURL 根据站点base_url生成绝对地址
+func AbsoluteURL(path string) string {
+	base := strings.TrimSuffix(config.AppConfig.Site.BaseURL, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return base + path
+}
+
+// ArticleURL 文章页面的永久链接
+func ArticleURL(slug string) string {
+	return AbsoluteURL("/articles/" + slug)
+}
+
+// AuthorURL 作者页面地址
+func AuthorURL(username string) string {
+	return AbsoluteURL("/authors/" + url.PathEscape(username))
+}
+
+// TagURL 标签页面地址
+func TagURL(slug string) string {
+	return AbsoluteURL("/tags/" + slug)
+}
+
+// ValidImageURL 检查图片地址是否为http(s)绝对地址或以/开头的站内路径
+func ValidImageURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return u.Host == "" && strings.HasPrefix(u.Path, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ValidWebURL 检查地址是否为http(s)绝对地址
+func ValidWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// RedirectPath 规范化站内路径用于旧地址匹配，去掉末尾的斜杠，保留查询参数
+func RedirectPath(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
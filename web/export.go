@@ -0,0 +1,468 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dingdinglz/test-blog/i18n"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+// manifestFile 记录上次导出的文章状态和生成的文件，用于增量导出
+const manifestFile = ".export-manifest.json"
+
+// exportManifest 导出清单
+type exportManifest struct {
+	ExportedAt time.Time                       `json:"exported_at"`
+	Articles   map[string]exportManifestRecord `json:"articles"`
+	Files      []string                        `json:"files"` // 导出目录下生成的文件，再次导出时删除不再生成的文件
+}
+
+type exportManifestRecord struct {
+	Slug        string    `json:"slug"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Fingerprint string    `json:"fingerprint"` // 文章页面中作者和标签的摘要，作者资料或标签修改后重新渲染
+}
+
+// ExportStats 导出统计
+type ExportStats struct {
+	Rendered  int
+	Skipped   int
+	Removed   int // 删除的不再生成的文件数
+	Redirects int
+}
+
+// redirectPage 历史slug的跳转页面，静态托管无法返回301
+var redirectPage = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.}}</title>
+  <link rel="canonical" href="{{.}}">
+  <meta http-equiv="refresh" content="0; url={{.}}">
+</head>
+<body><a href="{{.}}">{{.}}</a></body>
+</html>
+`))
+
+// exporter 静态站点导出器
+type exporter struct {
+	out    string
+	locale string
+	stats  ExportStats
+	files  map[string]bool // 本次导出生成的文件，相对导出目录
+}
+
+// ExportStatic 将站点导出为静态文件，force为false时只重新渲染UpdatedAt、slug、作者或标签变化的文章页面，
+// 并删除上次导出生成、本次不再生成的文件
+func ExportStatic(out string, force bool) (*ExportStats, error) {
+	e := &exporter{out: out, locale: i18n.DefaultLocale(), files: map[string]bool{}}
+
+	previous := e.readManifest()
+	if force {
+		previous.Articles = map[string]exportManifestRecord{}
+	}
+	current := exportManifest{ExportedAt: time.Now(), Articles: map[string]exportManifestRecord{}}
+
+	// 文章页面
+	articles, err := services.GetAllArticles(services.LoadAll)
+	if err != nil {
+		return nil, err
+	}
+	for i := range articles {
+		article := &articles[i]
+		id := strconv.FormatUint(uint64(article.ID), 10)
+		record := exportManifestRecord{Slug: article.Slug, UpdatedAt: article.UpdatedAt, Fingerprint: articleFingerprint(article)}
+		current.Articles[id] = record
+
+		if err := e.exportArticle(article, record, previous.Articles[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	// 历史slug跳转页面
+	redirects, err := services.GetSlugRedirects()
+	if err != nil {
+		return nil, err
+	}
+	for old, slug := range redirects {
+		segment, err := pathSegment(old)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.writeRedirect(filepath.Join("articles", segment), slug); err != nil {
+			return nil, err
+		}
+	}
+
+	// 导入文章的旧地址跳转页面，带查询参数的地址无法用静态文件表示
+	permalinks, err := services.GetArticleRedirects()
+	if err != nil {
+		return nil, err
+	}
+	for old, slug := range permalinks {
+		// 以/开头再Clean，去掉所有的..，根路径不生成跳转页面，避免覆盖首页
+		dir := strings.TrimPrefix(path.Clean("/"+old), "/")
+		if strings.Contains(old, "?") || dir == "" {
+			continue
+		}
+		if err := e.writeRedirect(filepath.FromSlash(dir), slug); err != nil {
+			return nil, err
+		}
+	}
+
+	steps := []func() error{e.exportIndex, e.exportAuthors, e.exportTags, e.exportFeeds, e.exportSitemap, e.exportStatic, e.exportNotFound}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	// 删除已删除的文章、作者、标签和旧slug等不再生成的文件
+	if err := e.prune(previous.Files); err != nil {
+		return nil, err
+	}
+
+	for file := range e.files {
+		current.Files = append(current.Files, file)
+	}
+	sort.Strings(current.Files)
+	if err := e.writeManifest(current); err != nil {
+		return nil, err
+	}
+
+	return &e.stats, nil
+}
+
+// writeRedirect 在dir下写入跳转到文章页面的index.html
+func (e *exporter) writeRedirect(dir, slug string) error {
+	var buf bytes.Buffer
+	if err := redirectPage.Execute(&buf, "/articles/"+url.PathEscape(slug)); err != nil {
+		return err
+	}
+	e.stats.Redirects++
+	return e.write(filepath.Join(dir, "index.html"), buf.Bytes())
+}
+
+// articleFingerprint 文章页面中作者和标签的摘要，这些内容修改时文章的UpdatedAt不变
+func articleFingerprint(article *models.Article) string {
+	tags := make([][2]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tags = append(tags, [2]string{tag.Slug, tag.Name})
+	}
+	data, _ := json.Marshal(struct {
+		Author [3]string
+		Tags   [][2]string
+	}{
+		Author: [3]string{article.User.Username, article.User.Name(), services.AvatarURL(&article.User)},
+		Tags:   tags,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// exportArticle 渲染文章页面，与上次导出相同且文件存在时跳过
+func (e *exporter) exportArticle(article *models.Article, record, previous exportManifestRecord) error {
+	segment, err := pathSegment(article.Slug)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join("articles", segment, "index.html")
+
+	if previous.Slug == record.Slug && previous.UpdatedAt.Equal(record.UpdatedAt) && previous.Fingerprint == record.Fingerprint {
+		if _, err := os.Stat(filepath.Join(e.out, path)); err == nil {
+			e.files[filepath.ToSlash(path)] = true
+			e.stats.Skipped++
+			return nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := RenderArticle(&buf, e.locale, article); err != nil {
+		return err
+	}
+	e.stats.Rendered++
+	return e.write(path, buf.Bytes())
+}
+
+// exportIndex 渲染首页及分页，多余的旧分页会被删除
+func (e *exporter) exportIndex() error {
+	pages, err := TotalHomePages()
+	if err != nil {
+		return err
+	}
+
+	for page := 1; page <= pages; page++ {
+		path := "index.html"
+		if page > 1 {
+			path = filepath.Join("page", strconv.Itoa(page), "index.html")
+		}
+
+		var buf bytes.Buffer
+		if err := RenderHome(&buf, e.locale, page); err != nil {
+			return err
+		}
+		if err := e.write(path, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(e.out, "page"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, entry := range entries {
+		if n, err := strconv.Atoi(entry.Name()); err == nil && n > pages {
+			if err := os.RemoveAll(filepath.Join(e.out, "page", entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportAuthors 渲染作者页面和作者订阅源
+func (e *exporter) exportAuthors() error {
+	users, err := services.GetAllUsers()
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		// 匿名用户只用于保留已注销用户的内容，没有作者页面
+		if user.Role == models.UserRoleGhost {
+			continue
+		}
+		segment, err := pathSegment(user.Username)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Join("authors", segment)
+
+		var buf bytes.Buffer
+		if err := RenderAuthor(&buf, e.locale, user.Username); err != nil {
+			return err
+		}
+		if err := e.write(filepath.Join(dir, "index.html"), buf.Bytes()); err != nil {
+			return err
+		}
+		if err := e.writeFeeds(dir, user.Username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportTags 渲染标签页面
+func (e *exporter) exportTags() error {
+	tags, err := services.GetAllTags()
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		segment, err := pathSegment(tag.Slug)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := RenderTag(&buf, e.locale, tag.Slug); err != nil {
+			return err
+		}
+		if err := e.write(filepath.Join("tags", segment, "index.html"), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportFeeds 导出全站订阅源
+func (e *exporter) exportFeeds() error {
+	return e.writeFeeds(".", "")
+}
+
+// writeFeeds 在dir下写入RSS、Atom和JSON Feed
+func (e *exporter) writeFeeds(dir, username string) error {
+	feed, err := services.BuildFeed(username)
+	if err != nil {
+		return err
+	}
+
+	formats := []struct {
+		name   string
+		render func() (string, error)
+	}{
+		{"feed.xml", feed.ToRss},
+		{"atom.xml", feed.ToAtom},
+		{"feed.json", feed.ToJSON},
+	}
+	for _, format := range formats {
+		body, err := format.render()
+		if err != nil {
+			return utils.ErrInternal.Wrap(err)
+		}
+		if err := e.write(filepath.Join(dir, format.name), []byte(body)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportSitemap 导出站点地图和robots.txt
+func (e *exporter) exportSitemap() error {
+	body, _, err := services.BuildSitemap(0)
+	if err != nil {
+		return err
+	}
+	if err := e.write("sitemap.xml", body); err != nil {
+		return err
+	}
+
+	pages, err := services.SitemapPages()
+	if err != nil {
+		return err
+	}
+	if pages > 1 {
+		for page := 1; page <= pages; page++ {
+			body, _, err := services.BuildSitemap(page)
+			if err != nil {
+				return err
+			}
+			if err := e.write(filepath.Join("sitemap", fmt.Sprintf("%d.xml", page)), body); err != nil {
+				return err
+			}
+		}
+	}
+
+	return e.write("robots.txt", []byte(services.BuildRobots()))
+}
+
+// exportStatic 复制主题静态文件
+func (e *exporter) exportStatic() error {
+	static := StaticFS()
+	return fs.WalkDir(static, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(static, path)
+		if err != nil {
+			return err
+		}
+		return e.write(filepath.Join("static", path), data)
+	})
+}
+
+// exportNotFound 导出404页面，多数静态托管服务会自动使用
+func (e *exporter) exportNotFound() error {
+	var buf bytes.Buffer
+	RenderError(&buf, e.locale, utils.ErrNotFound)
+	return e.write("404.html", buf.Bytes())
+}
+
+// pathSegment 把用户名、slug等转换为单级目录名：按URL路径转义，/ 等字符不会产生子目录，
+// 与页面链接中的 url.PathEscape 一致；. 和 .. 中的点同样转义
+func pathSegment(name string) (string, error) {
+	segment := url.PathEscape(name)
+	switch segment {
+	case "":
+		return "", fmt.Errorf("无法导出的路径: %q", name)
+	case ".", "..":
+		segment = strings.ReplaceAll(segment, ".", "%2E")
+	}
+	return segment, nil
+}
+
+// path 拼接导出目录下的路径，结果不在导出目录下时返回错误
+func (e *exporter) path(elem ...string) (string, error) {
+	full := filepath.Join(append([]string{e.out}, elem...)...)
+	rel, err := filepath.Rel(e.out, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("导出路径超出输出目录: %s", filepath.Join(elem...))
+	}
+	return full, nil
+}
+
+func (e *exporter) write(path string, data []byte) error {
+	full, err := e.path(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	if path != manifestFile {
+		e.files[filepath.ToSlash(filepath.Clean(path))] = true
+	}
+	return os.WriteFile(full, data, 0644)
+}
+
+// prune 删除上次导出生成、本次没有生成的文件，以及因此变为空的目录
+func (e *exporter) prune(previous []string) error {
+	for _, file := range previous {
+		if e.files[file] {
+			continue
+		}
+		full, err := e.path(filepath.FromSlash(file))
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(full); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		e.stats.Removed++
+
+		// 逐级删除空目录，不为空时Remove失败即停止
+		for dir := filepath.Dir(full); dir != filepath.Clean(e.out); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (e *exporter) readManifest() exportManifest {
+	manifest := exportManifest{Articles: map[string]exportManifestRecord{}}
+
+	data, err := os.ReadFile(filepath.Join(e.out, manifestFile))
+	if err != nil {
+		return manifest
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		log.Printf("导出清单损坏，将全量导出: %v\n", err)
+		return exportManifest{Articles: map[string]exportManifestRecord{}}
+	}
+	if manifest.Articles == nil {
+		manifest.Articles = map[string]exportManifestRecord{}
+	}
+	return manifest
+}
+
+func (e *exporter) writeManifest(manifest exportManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return e.write(manifestFile, data)
+}
@@ -0,0 +1,194 @@
+package web
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/database"
+	"github.com/dingdinglz/test-blog/i18n"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+)
+
+func TestMain(m *testing.M) {
+	if err := config.LoadConfig(); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	if err := i18n.Init(); err != nil {
+		log.Fatalf("加载语言包失败: %v", err)
+	}
+	if err := Init(); err != nil {
+		log.Fatalf("加载主题失败: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// setupDB 为测试创建独立的数据库
+func setupDB(t *testing.T) {
+	t.Helper()
+	config.AppConfig.Database.Path = filepath.Join(t.TempDir(), "blog.db")
+	if err := database.Init(); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := database.GetDB().DB(); err == nil {
+			db.Close()
+		}
+	})
+}
+
+// export 执行一次增量导出
+func export(t *testing.T, out string) ExportStats {
+	t.Helper()
+	stats, err := ExportStatic(out, false)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	return *stats
+}
+
+// assertFiles 检查导出目录中的文件是否存在
+func assertFiles(t *testing.T, out string, exist bool, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		_, err := os.Stat(filepath.Join(out, filepath.FromSlash(file)))
+		if (err == nil) != exist {
+			t.Errorf("%s 存在 %t，期望 %t", file, err == nil, exist)
+		}
+	}
+}
+
+func TestExportStaticIncremental(t *testing.T) {
+	setupDB(t)
+	out := t.TempDir()
+	db := database.GetDB()
+
+	alice, err := services.Register("alice", "secret123", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := services.Register("bob", "secret123", "bob@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ghost := models.User{Username: "ghost", Password: "-", Email: "ghost@ghost.invalid", Role: models.UserRoleGhost}
+	db.Create(&ghost)
+
+	first, err := services.CreateArticle(services.ArticleInput{Title: "First", Content: "一", Slug: "first", Tags: []string{"go"}}, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := services.CreateArticle(services.ArticleInput{Title: "Second", Content: "二", Slug: "second", Tags: []string{"misc"}}, bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := export(t, out)
+	if stats.Rendered != 2 || stats.Skipped != 0 {
+		t.Fatalf("首次导出 %+v，期望渲染2篇", stats)
+	}
+	assertFiles(t, out, true, "articles/first/index.html", "articles/second/index.html",
+		"authors/alice/index.html", "authors/bob/feed.xml", "tags/go/index.html", "tags/misc/index.html")
+	// 匿名用户没有作者页面
+	assertFiles(t, out, false, "authors/ghost")
+
+	if stats := export(t, out); stats.Rendered != 0 || stats.Skipped != 2 || stats.Removed != 0 {
+		t.Fatalf("未修改时导出 %+v，期望全部跳过", stats)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(t *testing.T)
+		rendered int
+		removed  []string
+		exists   []string
+		contains map[string]string
+	}{
+		{
+			name: "作者修改显示名称",
+			change: func(t *testing.T) {
+				name := "Alice Liddell"
+				if _, err := services.UpdateProfile(alice.ID, services.ProfileInput{DisplayName: &name}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			rendered: 1,
+			contains: map[string]string{"articles/first/index.html": "Alice Liddell"},
+		},
+		{
+			name: "标签改名",
+			change: func(t *testing.T) {
+				db.Model(&models.Tag{}).Where("slug = ?", "misc").Update("name", "杂记")
+			},
+			rendered: 1,
+			contains: map[string]string{"articles/second/index.html": "杂记"},
+		},
+		{
+			name: "修改slug后旧地址改为跳转页面",
+			change: func(t *testing.T) {
+				if _, err := services.UpdateArticle(first.ID, alice.ID, nil, services.ArticleInput{Title: "First", Content: "一", Slug: "first-renamed"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			rendered: 1,
+			exists:   []string{"articles/first-renamed/index.html"},
+			contains: map[string]string{"articles/first/index.html": `http-equiv="refresh"`},
+		},
+		{
+			name: "删除文章、标签和作者",
+			change: func(t *testing.T) {
+				if err := services.DeleteArticle(second.ID, bob.ID, nil); err != nil {
+					t.Fatal(err)
+				}
+				db.Exec("DELETE FROM article_tags WHERE tag_id IN (SELECT id FROM tags WHERE slug = ?)", "misc")
+				db.Unscoped().Where("slug = ?", "misc").Delete(&models.Tag{})
+				db.Unscoped().Delete(&models.User{}, bob.ID)
+			},
+			removed: []string{"articles/second", "tags/misc", "authors/bob"},
+			exists:  []string{"articles/first-renamed/index.html", "authors/alice/index.html", "tags/go/index.html"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change(t)
+			stats := export(t, out)
+			if stats.Rendered != tt.rendered {
+				t.Errorf("渲染 %d 篇，期望 %d 篇", stats.Rendered, tt.rendered)
+			}
+			assertFiles(t, out, false, tt.removed...)
+			assertFiles(t, out, true, tt.exists...)
+			for file, text := range tt.contains {
+				data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(file)))
+				if err != nil || !strings.Contains(string(data), text) {
+					t.Errorf("%s 不包含 %q", file, text)
+				}
+			}
+		})
+	}
+}
+
+func TestExportStaticPaths(t *testing.T) {
+	setupDB(t)
+	parent := t.TempDir()
+	out := filepath.Join(parent, "public")
+
+	// 用户名和跳转地址中的 .. 和 / 不能写到导出目录之外
+	user := models.User{Username: "..", Password: "-", Email: "dots@example.com"}
+	database.GetDB().Create(&user)
+	article, err := services.CreateArticle(services.ArticleInput{Title: "Escape", Content: "内容", Slug: "escape"}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.GetDB().Create(&models.ArticleRedirect{Path: "/../../outside/", ArticleID: article.ID})
+
+	export(t, out)
+	assertFiles(t, out, true, "authors/%2E%2E/index.html", "outside/index.html")
+	if _, err := os.Stat(filepath.Join(parent, "outside")); err == nil {
+		t.Errorf("跳转页面写到了导出目录之外")
+	}
+}
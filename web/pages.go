@@ -0,0 +1,131 @@
+package web
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/i18n"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+// PageData 页面模板数据
+type PageData struct {
+	Site       config.SiteConfig
+	Locale     string
+	Title      string
+	Articles   []models.Article
+	Article    *models.Article
+	Author     *models.User
+	Tag        *models.Tag
+	Page       int
+	TotalPages int
+	Status     int
+	Message    string
+}
+
+func newPageData(locale, title string) *PageData {
+	return &PageData{
+		Site:   config.AppConfig.Site,
+		Locale: locale,
+		Title:  title,
+	}
+}
+
+// TotalHomePages 首页分页数量
+func TotalHomePages() (int, error) {
+	_, total, err := services.GetArticlesPage(1, 1)
+	if err != nil {
+		return 0, err
+	}
+	return totalPages(total), nil
+}
+
+func totalPages(total int64) int {
+	pageSize := int64(config.AppConfig.Theme.PageSize)
+	pages := int((total + pageSize - 1) / pageSize)
+	if pages < 1 {
+		pages = 1
+	}
+	return pages
+}
+
+// RenderHome 渲染首页的第page页
+func RenderHome(w io.Writer, locale string, page int) error {
+	pageSize := config.AppConfig.Theme.PageSize
+
+	articles, total, err := services.GetArticlesPage(page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	pages := totalPages(total)
+	if page < 1 || page > pages {
+		return utils.ErrNotFound
+	}
+
+	data := newPageData(locale, config.AppConfig.Site.Title)
+	data.Articles = articles
+	data.Page = page
+	data.TotalPages = pages
+	return render(w, pageIndex, data)
+}
+
+// RenderArticle 渲染文章页面
+func RenderArticle(w io.Writer, locale string, article *models.Article) error {
+	data := newPageData(locale, article.Title)
+	data.Article = article
+	return render(w, pageArticle, data)
+}
+
+// RenderAuthor 渲染作者页面
+func RenderAuthor(w io.Writer, locale, username string) error {
+	user, err := services.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	articles, err := services.GetUserArticles(user.ID, services.LoadAll)
+	if err != nil {
+		return err
+	}
+
+	data := newPageData(locale, user.Username)
+	data.Author = user
+	data.Articles = articles
+	return render(w, pageAuthor, data)
+}
+
+// RenderTag 渲染标签页面
+func RenderTag(w io.Writer, locale, slug string) error {
+	tag, err := services.GetTagBySlug(slug)
+	if err != nil {
+		return err
+	}
+
+	articles, err := services.GetTagArticles(tag.ID, services.LoadAll)
+	if err != nil {
+		return err
+	}
+
+	data := newPageData(locale, tag.Name)
+	data.Tag = tag
+	data.Articles = articles
+	return render(w, pageTag, data)
+}
+
+// RenderError 渲染错误页面，返回对应的HTTP状态码
+func RenderError(w io.Writer, locale string, err error) int {
+	appErr := utils.AsAppError(err)
+	status := utils.StatusOf(appErr.Code)
+
+	data := newPageData(locale, http.StatusText(status))
+	data.Status = status
+	data.Message = i18n.Message(locale, string(appErr.Code), appErr.Message)
+	if renderErr := render(w, pageError, data); renderErr != nil {
+		io.WriteString(w, data.Message)
+	}
+	return status
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dingdinglz/test-blog/config"
+	"github.com/dingdinglz/test-blog/i18n"
+	"github.com/dingdinglz/test-blog/models"
+	"github.com/dingdinglz/test-blog/services"
+	"github.com/dingdinglz/test-blog/utils"
+)
+
+//go:embed themes
+var embedded embed.FS
+
+// 主题中的页面模板，每个页面与 layout.html 组合解析
+const (
+	pageIndex   = "index.html"
+	pageArticle = "article.html"
+	pageAuthor  = "author.html"
+	pageTag     = "tag.html"
+	pageError   = "error.html"
+)
+
+var pages = []string{pageIndex, pageArticle, pageAuthor, pageTag, pageError}
+
+var (
+	// themeFS 自定义主题目录优先，内置默认主题兜底
+	themeFS   fs.FS
+	templates map[string]*template.Template
+)
+
+// overlayFS 按顺序在多个文件系统中查找文件
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	for _, fsys := range o {
+		f, err := fsys.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// Init 加载主题模板
+func Init() error {
+	cfg := config.AppConfig.Theme
+
+	builtin, err := fs.Sub(embedded, "themes/default")
+	if err != nil {
+		return err
+	}
+	layers := overlayFS{builtin}
+
+	if cfg.Dir != "" && cfg.Name != "" {
+		dir := filepath.Join(cfg.Dir, cfg.Name)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			layers = append(overlayFS{os.DirFS(dir)}, layers...)
+			log.Printf("使用自定义主题: %s\n", dir)
+		} else if cfg.Name != "default" {
+			return fmt.Errorf("主题目录不存在: %s", dir)
+		}
+	}
+	themeFS = layers
+
+	templates = map[string]*template.Template{}
+	for _, page := range pages {
+		t, err := template.New(page).Funcs(funcs).ParseFS(themeFS, "layout.html", page)
+		if err != nil {
+			return fmt.Errorf("解析模板 %s 失败: %w", page, err)
+		}
+		templates[page] = t
+	}
+
+	return nil
+}
+
+// StaticFS 主题静态文件
+func StaticFS() fs.FS {
+	sub, err := fs.Sub(themeFS, "static")
+	if err != nil {
+		return themeFS
+	}
+	return sub
+}
+
+// funcs 模板函数，页面内链接使用相对站点根目录的路径
+var funcs = template.FuncMap{
+	"t": func(locale, key string) string {
+		return i18n.Message(locale, key, key)
+	},
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	"datetime": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+	"html": func(s string) template.HTML {
+		// 内容已在渲染时经过sanitizer清洗
+		return template.HTML(s)
+	},
+	"summary": func(contentHTML string) string {
+		return utils.Summarize(contentHTML, config.AppConfig.Feed.SummaryLength)
+	},
+	"excerpt": func(article models.Article) string {
+		return services.ArticleExcerpt(&article)
+	},
+	"avatar": func(user models.User) string {
+		return services.AvatarURL(&user)
+	},
+	"absURL": func(link string) string {
+		// og:image等需要绝对地址
+		if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") {
+			return utils.AbsoluteURL(link)
+		}
+		return link
+	},
+	"articlePath": func(slug string) string {
+		return "/articles/" + slug
+	},
+	"authorPath": func(username string) string {
+		return "/authors/" + url.PathEscape(username)
+	},
+	"tagPath": func(slug string) string {
+		return "/tags/" + slug
+	},
+	"pagePath": pagePath,
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"sub": func(a, b int) int {
+		return a - b
+	},
+}
+
+// pagePath 首页分页路径，第一页为站点根目录
+func pagePath(page int) string {
+	if page <= 1 {
+		return "/"
+	}
+	return fmt.Sprintf("/page/%d", page)
+}
+
+// render 使用布局渲染页面
+func render(w io.Writer, page string, data interface{}) error {
+	t, ok := templates[page]
+	if !ok {
+		return fmt.Errorf("模板 %s 未加载", page)
+	}
+	return t.ExecuteTemplate(w, "layout", data)
+}